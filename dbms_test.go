@@ -39,6 +39,15 @@ func TestQueriesPerDBMS(t *testing.T) {
 		DBMSSQLServer,
 		DBMSMySQL,
 		DBMSSnowflake,
+		DBMSTrino,
+		DBMSSparkSQL,
+		DBMSCockroachDB,
+		DBMSDuckDB,
+		DBMSCassandra,
+		DBMSPartiQL,
+		DBMSTeradata,
+		DBMSMariaDB,
+		DBMSTiDB,
 	}
 
 	for _, dbms := range dbmsTypes {
@@ -115,6 +124,9 @@ func TestQueriesPerDBMS(t *testing.T) {
 							WithReplaceBoolean(defaultObfuscatorConfig.ReplaceBoolean),
 							WithReplaceNull(defaultObfuscatorConfig.ReplaceNull),
 							WithKeepJsonPath(defaultObfuscatorConfig.KeepJsonPath),
+							WithReplaceSubstitutionVar(defaultObfuscatorConfig.ReplaceSubstitutionVar),
+							WithKeepFilePath(defaultObfuscatorConfig.KeepFilePath),
+							WithEmbeddedSQL(defaultObfuscatorConfig.EmbeddedSQL),
 						)
 
 						normalizer := NewNormalizer(
@@ -127,6 +139,16 @@ func TestQueriesPerDBMS(t *testing.T) {
 							WithRemoveSpaceBetweenParentheses(defaultNormalizerConfig.RemoveSpaceBetweenParentheses),
 							WithKeepTrailingSemicolon(defaultNormalizerConfig.KeepTrailingSemicolon),
 							WithKeepIdentifierQuotation(defaultNormalizerConfig.KeepIdentifierQuotation),
+							WithCollectTableAccesses(defaultNormalizerConfig.CollectTableAccesses),
+							WithCollectTableRefs(defaultNormalizerConfig.CollectTableRefs),
+							WithFoldIdentifierCase(defaultNormalizerConfig.FoldIdentifierCase),
+							WithFoldIdentifierCaseInOutput(defaultNormalizerConfig.FoldIdentifierCaseInOutput),
+							WithCollectColumns(defaultNormalizerConfig.CollectColumns),
+							WithCollectFunctions(defaultNormalizerConfig.CollectFunctions),
+							WithCollectPredicates(defaultNormalizerConfig.CollectPredicates),
+							WithCollectStructure(defaultNormalizerConfig.CollectStructure),
+							WithCollectSchemas(defaultNormalizerConfig.CollectSchemas),
+							WithNormalizeEmbeddedSQL(defaultNormalizerConfig.NormalizeEmbeddedSQL),
 						)
 
 						got, statementMetadata, err := ObfuscateAndNormalize(string(tt.Input), obfuscator, normalizer, WithDBMS(dbms))
@@ -0,0 +1,157 @@
+package sqllexer
+
+import "strings"
+
+// newFunctionSet builds a set of upper-cased function names from a whitespace separated list
+func newFunctionSet(names string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, name := range strings.Fields(names) {
+		set[name] = struct{}{}
+	}
+	return set
+}
+
+// builtinFunctions are the functions built into most dialects
+var builtinFunctions = newFunctionSet(`
+	COUNT SUM AVG MIN MAX STDDEV STDDEV_POP STDDEV_SAMP VARIANCE VAR_POP VAR_SAMP COVAR_POP COVAR_SAMP CORR
+	ARRAY_AGG STRING_AGG LISTAGG GROUP_CONCAT BOOL_AND BOOL_OR EVERY PERCENTILE_CONT PERCENTILE_DISC MODE GROUPING
+	ROW_NUMBER RANK DENSE_RANK PERCENT_RANK CUME_DIST NTILE LAG LEAD FIRST_VALUE LAST_VALUE NTH_VALUE
+	ABS CEIL CEILING FLOOR ROUND TRUNC MOD POWER POW SQRT EXP LN LOG LOG10 LOG2 SIGN PI
+	SIN COS TAN ASIN ACOS ATAN ATAN2 DEGREES RADIANS GREATEST LEAST
+	COALESCE NULLIF CAST TRY_CAST CONVERT
+	LENGTH CHAR_LENGTH CHARACTER_LENGTH OCTET_LENGTH BIT_LENGTH LOWER UPPER INITCAP TRIM LTRIM RTRIM
+	SUBSTRING SUBSTR LPAD RPAD CONCAT CONCAT_WS POSITION REVERSE REPEAT TRANSLATE ASCII CHR CHAR MD5
+	REGEXP_REPLACE REGEXP_SUBSTR REGEXP_LIKE REGEXP_INSTR REGEXP_COUNT FORMAT TO_CHAR TO_DATE TO_TIMESTAMP TO_NUMBER
+	EXTRACT DATE_TRUNC DATE_PART DATEADD DATEDIFF DATE_ADD DATE_SUB DATE_FORMAT LAST_DAY ADD_MONTHS
+	YEAR MONTH DAY HOUR MINUTE SECOND WEEK QUARTER DATE
+	JSON_EXTRACT JSON_VALUE JSON_QUERY JSON_OBJECT JSON_ARRAY JSON_ARRAYAGG JSON_OBJECTAGG
+	UNNEST CARDINALITY
+	NOW CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP LOCALTIME LOCALTIMESTAMP RAND RANDOM UUID
+`)
+
+// dialectBuiltinFunctions are the functions built into a single dialect or a family of dialects
+var dialectBuiltinFunctions = map[DBMSType]map[string]struct{}{
+	DBMSPostgres: newFunctionSet(`
+		BTRIM STRPOS SPLIT_PART AGE CLOCK_TIMESTAMP STATEMENT_TIMESTAMP TRANSACTION_TIMESTAMP TIMEOFDAY
+		GEN_RANDOM_UUID SETSEED NEXTVAL CURRVAL SETVAL LASTVAL PG_SLEEP CURRENT_SETTING SET_CONFIG
+		GENERATE_SERIES ARRAY_LENGTH ARRAY_TO_STRING STRING_TO_ARRAY ARRAY_POSITION ARRAY_APPEND ARRAY_REMOVE
+		REGEXP_MATCHES JSON_AGG JSONB_AGG JSON_BUILD_OBJECT JSONB_BUILD_OBJECT TO_JSON TO_JSONB JSONB_SET
+		JSON_EXTRACT_PATH JSONB_EXTRACT_PATH JSONB_ARRAY_ELEMENTS JSON_ARRAY_ELEMENTS
+		TO_TSVECTOR TO_TSQUERY PLAINTO_TSQUERY TS_RANK MAKE_DATE MAKE_INTERVAL`),
+	DBMSMySQL: newFunctionSet(`
+		IFNULL LCASE UCASE LOCATE INSTR FIND_IN_SET FIELD ELT HEX UNHEX BIN CONV SHA1 SHA2 INET_ATON INET_NTOA
+		JSON_UNQUOTE JSON_CONTAINS JSON_SET JSON_LENGTH JSON_KEYS ANY_VALUE BIT_COUNT
+		CURDATE CURTIME SYSDATE UTC_DATE UTC_TIME UTC_TIMESTAMP UNIX_TIMESTAMP FROM_UNIXTIME STR_TO_DATE
+		TIMESTAMPDIFF TIMESTAMPADD TO_DAYS FROM_DAYS DAYOFWEEK DAYOFMONTH DAYOFYEAR
+		UUID_SHORT RANDOM_BYTES CONNECTION_ID LAST_INSERT_ID FOUND_ROWS ROW_COUNT SLEEP DATABASE USER VERSION`),
+	DBMSSQLServer: newFunctionSet(`
+		ISNULL IIF CHOOSE TRY_CONVERT TRY_PARSE PARSE LEN DATALENGTH CHARINDEX PATINDEX REPLICATE STUFF SPACE STR
+		QUOTENAME UNICODE NCHAR SOUNDEX DIFFERENCE STRING_SPLIT OPENJSON ISJSON COUNT_BIG GROUPING_ID
+		DATEPART DATENAME EOMONTH DATEFROMPARTS SWITCHOFFSET TODATETIMEOFFSET CHECKSUM BINARY_CHECKSUM HASHBYTES
+		GETDATE GETUTCDATE SYSDATETIME SYSUTCDATETIME SYSDATETIMEOFFSET NEWID NEWSEQUENTIALID CRYPT_GEN_RANDOM
+		SCOPE_IDENTITY ROWCOUNT_BIG OBJECT_ID OBJECT_NAME DB_NAME SUSER_SNAME ERROR_MESSAGE ERROR_NUMBER`),
+	DBMSOracle: newFunctionSet(`
+		NVL NVL2 DECODE INSTR SYS_CONTEXT USERENV SYS_GUID SYSDATE SYSTIMESTAMP TO_CLOB RAWTOHEX HEXTORAW
+		NUMTODSINTERVAL NUMTOYMINTERVAL MONTHS_BETWEEN STANDARD_HASH ORA_HASH SUBSTRB LENGTHB`),
+	DBMSSnowflake: newFunctionSet(`
+		IFF NVL NVL2 DECODE ZEROIFNULL NULLIFZERO EQUAL_NULL PARSE_JSON TRY_PARSE_JSON OBJECT_CONSTRUCT ARRAY_CONSTRUCT
+		FLATTEN GET GET_PATH TO_VARIANT TO_VARCHAR TRY_TO_NUMBER TRY_TO_DATE TRY_TO_TIMESTAMP SPLIT SPLIT_PART STRTOK
+		CONTAINS STARTSWITH ENDSWITH DATE_FROM_PARTS TIMESTAMP_FROM_PARTS HASH SYSDATE
+		UUID_STRING RANDSTR UNIFORM SEQ4 SEQ8 GENERATOR RESULT_SCAN LAST_QUERY_ID IDENTIFIER`),
+	DBMSTrino: newFunctionSet(`
+		APPROX_DISTINCT APPROX_PERCENTILE ARBITRARY ELEMENT_AT TRANSFORM REDUCE SEQUENCE TRY TYPEOF HISTOGRAM
+		FROM_UNIXTIME TO_UNIXTIME DATE_PARSE FORMAT_DATETIME CURRENT_TIMEZONE STRPOS SPLIT SPLIT_PART
+		JSON_EXTRACT_SCALAR JSON_PARSE JSON_FORMAT MAP MAP_AGG ARRAY_JOIN CONTAINS REGEXP_EXTRACT URL_EXTRACT_HOST`),
+	DBMSSparkSQL: newFunctionSet(`
+		NVL IF COLLECT_LIST COLLECT_SET EXPLODE EXPLODE_OUTER POSEXPLODE SIZE GET_JSON_OBJECT FROM_JSON TO_JSON
+		NAMED_STRUCT STRUCT MAP ARRAY ARRAY_CONTAINS SPLIT REGEXP_EXTRACT UNIX_TIMESTAMP FROM_UNIXTIME
+		SHA1 SHA2 CRC32 XXHASH64 APPROX_PERCENTILE PERCENTILE_APPROX MONOTONICALLY_INCREASING_ID INPUT_FILE_NAME`),
+	DBMSCockroachDB: newFunctionSet(`
+		BTRIM STRPOS SPLIT_PART AGE CLOCK_TIMESTAMP STATEMENT_TIMESTAMP TRANSACTION_TIMESTAMP GEN_RANDOM_UUID
+		UNIQUE_ROWID UUID_V4 NEXTVAL CURRVAL GENERATE_SERIES ARRAY_LENGTH ARRAY_TO_STRING STRING_TO_ARRAY
+		JSON_BUILD_OBJECT JSONB_BUILD_OBJECT TO_JSON TO_JSONB JSONB_SET`),
+	DBMSDuckDB: newFunctionSet(`
+		READ_CSV READ_CSV_AUTO READ_PARQUET PARQUET_SCAN READ_JSON READ_JSON_AUTO READ_NDJSON READ_NDJSON_AUTO
+		READ_TEXT READ_BLOB DELTA_SCAN ICEBERG_SCAN SNIFF_CSV PARQUET_METADATA PARQUET_SCHEMA READ_JSON_OBJECTS
+		LIST LIST_VALUE STRUCT_PACK STRFTIME STRPTIME EPOCH EPOCH_MS REGEXP_MATCHES STRING_SPLIT GENERATE_SERIES
+		GEN_RANDOM_UUID IFNULL NEXTVAL CURRVAL`),
+	DBMSCassandra: newFunctionSet(`
+		TOKEN TTL WRITETIME TOTIMESTAMP TODATE TOUNIXTIMESTAMP MINTIMEUUID MAXTIMEUUID
+		CURRENTTIMESTAMP CURRENTDATE CURRENTTIME CURRENTTIMEUUID BLOBASTEXT TEXTASBLOB`),
+	DBMSTeradata: newFunctionSet(`
+		NVL ZEROIFNULL NULLIFZERO OREPLACE OTRANSLATE INDEX CHARACTERS HASHROW HASHBUCKET HASHAMP`),
+	DBMSPartiQL: newFunctionSet(`
+		EXISTS SIZE BEGINS_WITH CONTAINS ATTRIBUTE_EXISTS ATTRIBUTE_NOT_EXISTS ATTRIBUTE_TYPE`),
+}
+
+// nonDeterministicFunctions are the built-in functions returning a different result for the same arguments,
+// e.g. the current time, random values or session state, which prevents caching the result of a query
+var nonDeterministicFunctions = newFunctionSet(`
+	NOW CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP LOCALTIME LOCALTIMESTAMP SYSDATE SYSTIMESTAMP
+	CURDATE CURTIME UTC_DATE UTC_TIME UTC_TIMESTAMP UNIX_TIMESTAMP CLOCK_TIMESTAMP STATEMENT_TIMESTAMP
+	TRANSACTION_TIMESTAMP TIMEOFDAY GETDATE GETUTCDATE SYSDATETIME SYSUTCDATETIME SYSDATETIMEOFFSET
+	CURRENTTIMESTAMP CURRENTDATE CURRENTTIME CURRENTTIMEUUID CURRENT_TIMEZONE
+	RAND RANDOM SETSEED UUID UUID_SHORT UUID_V4 UUID_STRING GEN_RANDOM_UUID NEWID NEWSEQUENTIALID SYS_GUID
+	RANDOM_BYTES CRYPT_GEN_RANDOM RANDSTR UNIFORM SEQ4 SEQ8 UNIQUE_ROWID MONOTONICALLY_INCREASING_ID
+	NEXTVAL CURRVAL SETVAL LASTVAL LAST_INSERT_ID SCOPE_IDENTITY FOUND_ROWS ROW_COUNT ROWCOUNT_BIG
+	CONNECTION_ID LAST_QUERY_ID INPUT_FILE_NAME SLEEP PG_SLEEP
+	DBMS_RANDOM.VALUE DBMS_RANDOM.STRING DBMS_RANDOM.RANDOM DBMS_RANDOM.NORMAL
+`)
+
+// functionLikeTypes are the types the lexer reads as functions when they take a length or a precision,
+// e.g. VARCHAR(20) or DECIMAL(10, 2)
+var functionLikeTypes = newFunctionSet(`
+	CHAR CHARACTER VARCHAR NCHAR NVARCHAR VARCHAR2 NVARCHAR2 BINARY VARBINARY BIT RAW TEXT
+	DECIMAL NUMERIC NUMBER FLOAT DOUBLE INT INTEGER BIGINT SMALLINT TINYINT MEDIUMINT
+	TIME TIMESTAMP DATETIME DATETIME2 DATETIMEOFFSET ENUM VECTOR
+`)
+
+// functionNameKeywords are the keywords followed by a function or a type which is not called by an expression,
+// e.g. f in CREATE FUNCTION f(a INT), p in CALL p(1) or t in CREATE TYPE t(...)
+var functionNameKeywords = newFunctionSet(`FUNCTION PROCEDURE PROC CALL EXEC EXECUTE TYPE`)
+
+// tableFunctionKeywords are the keywords followed by a table function, e.g. FROM generate_series(1, 10)
+var tableFunctionKeywords = newFunctionSet(`FROM JOIN USING LATERAL APPLY`)
+
+// systemSchemas are the schemas qualifying built-in functions, e.g. pg_catalog.now()
+var systemSchemas = newFunctionSet(`PG_CATALOG SYS`)
+
+// isBuiltinFunction checks if a function, qualified as written in any case, is built into the dialect,
+// e.g. NOW in PostgreSQL, pg_catalog.now in PostgreSQL or DBMS_RANDOM.VALUE in Oracle
+func isBuiltinFunction(name string, dbms DBMSType) bool {
+	if schema, function, ok := strings.Cut(name, "."); ok {
+		if _, ok := lookupKeyword(systemSchemas, schema); ok && !strings.Contains(function, ".") {
+			return isBuiltinFunction(function, dbms)
+		}
+		// Oracle packages, e.g. DBMS_RANDOM.VALUE or UTL_RAW.CAST_TO_RAW
+		return dbms == DBMSOracle && (hasPrefixFold(name, "DBMS_") || hasPrefixFold(name, "UTL_"))
+	}
+	if _, ok := lookupKeyword(builtinFunctions, name); ok {
+		return true
+	}
+	if isMySQLFamily(dbms) {
+		dbms = DBMSMySQL
+	}
+	if _, ok := lookupKeyword(dialectBuiltinFunctions[dbms], name); ok {
+		return true
+	}
+	// PostgreSQL system functions, e.g. pg_backend_pid()
+	return (dbms == DBMSPostgres || dbms == DBMSCockroachDB) && hasPrefixFold(name, "PG_")
+}
+
+// isNonDeterministicFunction checks if a built-in function, qualified as written in any case,
+// returns a different result for the same arguments, e.g. NOW() or RAND()
+func isNonDeterministicFunction(name string) bool {
+	if schema, function, ok := strings.Cut(name, "."); ok {
+		if _, ok := lookupKeyword(systemSchemas, schema); ok {
+			name = function
+		}
+	}
+	_, ok := lookupKeyword(nonDeterministicFunctions, name)
+	return ok
+}
+
+// hasPrefixFold checks if a name starts with an upper-cased prefix in any case, e.g. pg_ in pg_backend_pid
+func hasPrefixFold(name, prefix string) bool {
+	return len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
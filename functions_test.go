@@ -0,0 +1,33 @@
+package sqllexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBuiltinFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		dbms     DBMSType
+		expected bool
+	}{
+		{name: "COUNT", dbms: DBMSPostgres, expected: true},
+		{name: "PG_CATALOG.NOW", dbms: DBMSPostgres, expected: true},
+		{name: "PG_BACKEND_PID", dbms: DBMSPostgres, expected: true},
+		{name: "GETDATE", dbms: DBMSPostgres, expected: false},
+		{name: "GETDATE", dbms: DBMSSQLServer, expected: true},
+		{name: "SYS.FN_LISTEXTENDEDPROPERTY", dbms: DBMSSQLServer, expected: false},
+		{name: "FIND_IN_SET", dbms: DBMSMariaDB, expected: true},
+		{name: "UTL_RAW.CAST_TO_RAW", dbms: DBMSOracle, expected: true},
+		{name: "MY_PKG.F", dbms: DBMSOracle, expected: false},
+		{name: "IFF", dbms: DBMSSnowflake, expected: true},
+		{name: "DBO.MY_UDF", dbms: DBMSSQLServer, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, isBuiltinFunction(test.name, test.dbms))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package sqllexer
+
+// StatementLineage is the table-level lineage of a single statement of the input
+type StatementLineage struct {
+	Statement              // the statement and its byte range in the input
+	Targets       []string // tables written by the statement, e.g. tgt in INSERT INTO tgt SELECT ... FROM src
+	Sources       []string // tables read by the statement, e.g. src in INSERT INTO tgt SELECT ... FROM src
+	Intermediates []string // CTEs defined by the statement, whose tables are reported as sources
+}
+
+// Lineage splits the input into its statements with SplitStatements and returns the tables each statement
+// writes and reads, e.g. for INSERT INTO tgt SELECT ..., CREATE TABLE tgt AS SELECT ..., MERGE INTO tgt USING src
+// or SELECT ... INTO tgt FROM src in SQL Server. Tables and table accesses are collected regardless of the
+// configuration of the normalizer.
+// Snowflake stages are sources of a statement writing a table, e.g. COPY INTO tgt FROM @stage,
+// and targets otherwise, e.g. COPY INTO @stage FROM src.
+func (n *Normalizer) Lineage(input string, lexerOpts ...lexerOption) ([]StatementLineage, error) {
+	config := *n.config
+	config.CollectTables = true
+	config.CollectTableAccesses = true
+	config.MergeStatements = false
+	normalizer := &Normalizer{config: &config}
+
+	result, err := normalizer.NormalizeMulti(input, lexerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	lineages := make([]StatementLineage, 0, len(result.Statements))
+	for _, statement := range result.Statements {
+		lineages = append(lineages, newStatementLineage(statement))
+	}
+	return lineages, nil
+}
+
+func newStatementLineage(statement NormalizedStatement) StatementLineage {
+	lineage := StatementLineage{
+		Statement:     statement.Statement,
+		Targets:       []string{},
+		Sources:       []string{},
+		Intermediates: []string{},
+	}
+	targets, sources := map[string]struct{}{}, map[string]struct{}{}
+	add := func(name string, set map[string]struct{}, slice *[]string) {
+		if _, exists := set[name]; !exists {
+			set[name] = struct{}{}
+			*slice = append(*slice, name)
+		}
+	}
+
+	metadata := statement.StatementMetadata
+	for _, access := range metadata.TableAccesses {
+		if access.Role == TableAccessWrite {
+			add(access.Name, targets, &lineage.Targets)
+		} else {
+			add(access.Name, sources, &lineage.Sources)
+		}
+	}
+	writesTable := len(lineage.Targets) > 0
+	for _, stage := range metadata.Stages {
+		if writesTable {
+			add(stage, sources, &lineage.Sources)
+		} else {
+			add(stage, targets, &lineage.Targets)
+		}
+	}
+	lineage.Intermediates = append(lineage.Intermediates, metadata.CTEs...)
+	return lineage
+}
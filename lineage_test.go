@@ -0,0 +1,123 @@
+package sqllexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		dbms     DBMSType
+		expected []StatementLineage
+	}{
+		{
+			name:  "insert select",
+			input: "INSERT INTO tgt (id, total) SELECT a.id, SUM(b.amount) FROM a JOIN b ON a.id = b.a_id GROUP BY a.id",
+			dbms:  DBMSPostgres,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "INSERT INTO tgt (id, total) SELECT a.id, SUM(b.amount) FROM a JOIN b ON a.id = b.a_id GROUP BY a.id", Start: 0, End: 99},
+					Targets:       []string{"tgt"},
+					Sources:       []string{"a", "b"},
+					Intermediates: []string{},
+				},
+			},
+		},
+		{
+			name:  "create table as select with ctes",
+			input: "CREATE TABLE tgt AS WITH recent AS (SELECT * FROM orders), totals AS (SELECT customer_id, COUNT(*) FROM recent GROUP BY customer_id) SELECT * FROM totals JOIN customers c ON c.id = totals.customer_id",
+			dbms:  DBMSPostgres,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "CREATE TABLE tgt AS WITH recent AS (SELECT * FROM orders), totals AS (SELECT customer_id, COUNT(*) FROM recent GROUP BY customer_id) SELECT * FROM totals JOIN customers c ON c.id = totals.customer_id", Start: 0, End: 199},
+					Targets:       []string{"tgt"},
+					Sources:       []string{"orders", "customers"},
+					Intermediates: []string{"recent", "totals"},
+				},
+			},
+		},
+		{
+			name:  "merge",
+			input: "MERGE INTO tgt t USING (SELECT id, v FROM src) s ON (t.id = s.id) WHEN MATCHED THEN UPDATE SET t.v = s.v",
+			dbms:  DBMSOracle,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "MERGE INTO tgt t USING (SELECT id, v FROM src) s ON (t.id = s.id) WHEN MATCHED THEN UPDATE SET t.v = s.v", Start: 0, End: 104},
+					Targets:       []string{"tgt"},
+					Sources:       []string{"src"},
+					Intermediates: []string{},
+				},
+			},
+		},
+		{
+			name:  "copy into from stage and unload to stage",
+			input: "COPY INTO tgt FROM @raw/2024/; COPY INTO @export/tgt FROM (SELECT * FROM tgt)",
+			dbms:  DBMSSnowflake,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "COPY INTO tgt FROM @raw/2024/", Start: 0, End: 29},
+					Targets:       []string{"tgt"},
+					Sources:       []string{"@raw"},
+					Intermediates: []string{},
+				},
+				{
+					Statement:     Statement{Text: "COPY INTO @export/tgt FROM (SELECT * FROM tgt)", Start: 31, End: 77},
+					Targets:       []string{"@export"},
+					Sources:       []string{"tgt"},
+					Intermediates: []string{},
+				},
+			},
+		},
+		{
+			name:  "select into",
+			input: "SELECT o.id, c.name INTO tgt FROM orders o JOIN customers c ON c.id = o.customer_id",
+			dbms:  DBMSSQLServer,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "SELECT o.id, c.name INTO tgt FROM orders o JOIN customers c ON c.id = o.customer_id", Start: 0, End: 83},
+					Targets:       []string{"tgt"},
+					Sources:       []string{"orders", "customers"},
+					Intermediates: []string{},
+				},
+			},
+		},
+		{
+			name:  "script",
+			input: "TRUNCATE TABLE stage_orders;\nINSERT INTO stage_orders SELECT * FROM orders;\nSELECT COUNT(*) FROM stage_orders;",
+			dbms:  DBMSMySQL,
+			expected: []StatementLineage{
+				{
+					Statement:     Statement{Text: "TRUNCATE TABLE stage_orders", Start: 0, End: 27},
+					Targets:       []string{"stage_orders"},
+					Sources:       []string{},
+					Intermediates: []string{},
+				},
+				{
+					Statement:     Statement{Text: "INSERT INTO stage_orders SELECT * FROM orders", Start: 29, End: 74},
+					Targets:       []string{"stage_orders"},
+					Sources:       []string{"orders"},
+					Intermediates: []string{},
+				},
+				{
+					Statement:     Statement{Text: "SELECT COUNT(*) FROM stage_orders", Start: 76, End: 109},
+					Targets:       []string{},
+					Sources:       []string{"stage_orders"},
+					Intermediates: []string{},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// lineage does not depend on the metadata collected by the normalizer
+			normalizer := NewNormalizer()
+			lineage, err := normalizer.Lineage(test.input, WithDBMS(test.dbms))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, lineage)
+		})
+	}
+}
@@ -34,6 +34,49 @@ type normalizerConfig struct {
 
 	// KeepIdentifierQuotation specifies whether the normalizer should keep the quotation of identifiers.
 	KeepIdentifierQuotation bool `json:"keep_identifier_quotation"`
+
+	// MergeStatements specifies whether NormalizeMulti should also return the merged view of all statements,
+	// i.e. the normalized statements joined with semicolons and their combined metadata, as Normalize returns them.
+	MergeStatements bool `json:"merge_statements"`
+
+	// CollectTableAccesses specifies whether the normalizer should also report how each table is accessed,
+	// i.e. the operation reading or writing the table. It requires CollectTables.
+	CollectTableAccesses bool `json:"collect_table_accesses"`
+
+	// CollectTableRefs specifies whether the normalizer should also report the tables split into catalog, schema and name.
+	// It requires CollectTables.
+	CollectTableRefs bool `json:"collect_table_refs"`
+
+	// FoldIdentifierCase specifies whether the names collected as metadata should be folded the way the dialect
+	// folds unquoted identifiers, e.g. Users to users in PostgreSQL or to USERS in Oracle and Snowflake.
+	// Quoted identifiers, and the quoted parts of qualified names, are kept exact.
+	FoldIdentifierCase bool `json:"fold_identifier_case"`
+
+	// FoldIdentifierCaseInOutput specifies whether the identifiers of the normalized SQL should be folded as well,
+	// which also folds the names collected as metadata.
+	FoldIdentifierCaseInOutput bool `json:"fold_identifier_case_in_output"`
+
+	// CollectColumns specifies whether the normalizer should extract the columns a query references, with the clause referencing them
+	CollectColumns bool `json:"collect_columns"`
+
+	// CollectFunctions specifies whether the normalizer should extract the functions a query calls
+	CollectFunctions bool `json:"collect_functions"`
+
+	// CollectPredicates specifies whether the normalizer should extract the predicates comparing a column in WHERE, ON and HAVING
+	CollectPredicates bool `json:"collect_predicates"`
+
+	// CollectStructure specifies whether the normalizer should report the structure of a query, i.e. its CTEs,
+	// its subquery depth, its set operations and its joins
+	CollectStructure bool `json:"collect_structure"`
+
+	// CollectSchemas specifies whether the normalizer should report the databases and schemas a statement runs against,
+	// i.e. the ones selected by USE, SET search_path, ALTER SESSION SET CURRENT_SCHEMA or \connect,
+	// and the catalogs and schemas qualifying its tables, which requires CollectTables.
+	CollectSchemas bool `json:"collect_schemas"`
+
+	// NormalizeEmbeddedSQL specifies whether the normalizer should normalize the SQL statements held by string literals,
+	// e.g. EXECUTE IMMEDIATE '...', and merge their metadata. Otherwise they are read as ordinary string literals.
+	NormalizeEmbeddedSQL bool `json:"normalize_embedded_sql"`
 }
 
 type normalizerOption func(*normalizerConfig)
@@ -44,206 +87,1858 @@ func WithCollectTables(collectTables bool) normalizerOption {
 	}
 }
 
-func WithCollectCommands(collectCommands bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.CollectCommands = collectCommands
+func WithCollectCommands(collectCommands bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectCommands = collectCommands
+	}
+}
+
+func WithCollectComments(collectComments bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectComments = collectComments
+	}
+}
+
+func WithKeepSQLAlias(keepSQLAlias bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.KeepSQLAlias = keepSQLAlias
+	}
+}
+
+func WithUppercaseKeywords(uppercaseKeywords bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.UppercaseKeywords = uppercaseKeywords
+	}
+}
+
+func WithCollectProcedures(collectProcedure bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectProcedure = collectProcedure
+	}
+}
+
+func WithRemoveSpaceBetweenParentheses(removeSpaceBetweenParentheses bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.RemoveSpaceBetweenParentheses = removeSpaceBetweenParentheses
+	}
+}
+
+func WithKeepTrailingSemicolon(keepTrailingSemicolon bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.KeepTrailingSemicolon = keepTrailingSemicolon
+	}
+}
+
+func WithKeepIdentifierQuotation(keepIdentifierQuotation bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.KeepIdentifierQuotation = keepIdentifierQuotation
+	}
+}
+
+func WithMergeStatements(mergeStatements bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.MergeStatements = mergeStatements
+	}
+}
+
+func WithCollectTableAccesses(collectTableAccesses bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectTableAccesses = collectTableAccesses
+	}
+}
+
+func WithCollectTableRefs(collectTableRefs bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectTableRefs = collectTableRefs
+	}
+}
+
+func WithFoldIdentifierCase(foldIdentifierCase bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.FoldIdentifierCase = foldIdentifierCase
+	}
+}
+
+func WithFoldIdentifierCaseInOutput(foldIdentifierCaseInOutput bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.FoldIdentifierCaseInOutput = foldIdentifierCaseInOutput
+	}
+}
+
+func WithCollectColumns(collectColumns bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectColumns = collectColumns
+	}
+}
+
+func WithCollectFunctions(collectFunctions bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectFunctions = collectFunctions
+	}
+}
+
+func WithCollectPredicates(collectPredicates bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectPredicates = collectPredicates
+	}
+}
+
+func WithCollectStructure(collectStructure bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectStructure = collectStructure
+	}
+}
+
+func WithCollectSchemas(collectSchemas bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectSchemas = collectSchemas
+	}
+}
+
+func WithNormalizeEmbeddedSQL(normalizeEmbeddedSQL bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.NormalizeEmbeddedSQL = normalizeEmbeddedSQL
+	}
+}
+
+type StatementMetadata struct {
+	Size       int      `json:"size"`
+	Tables     []string `json:"tables"`
+	Comments   []string `json:"comments"`
+	Commands   []string `json:"commands"`
+	Procedures []string `json:"procedures"`
+	IndexHints []string `json:"index_hints,omitempty"` // e.g. users_email_idx in CockroachDB users@users_email_idx
+	FilePaths  []string `json:"file_paths,omitempty"`  // files read or written as tables, e.g. data/*.parquet in DuckDB, only when not obfuscated
+	LockHints  []string `json:"lock_hints,omitempty"`  // e.g. ROW FOR ACCESS in Teradata LOCKING ROW FOR ACCESS
+	Sequences  []string `json:"sequences,omitempty"`   // e.g. order_seq in NEXT VALUE FOR order_seq
+	Stages     []string `json:"stages,omitempty"`      // e.g. @my_stage in Snowflake COPY INTO t FROM @my_stage/path/
+	DBLinks    []string `json:"db_links,omitempty"`    // e.g. remote_db in Oracle orders@remote_db
+	Packages   []string `json:"packages,omitempty"`    // e.g. billing in Oracle billing.charge(...)
+	TempTables []string `json:"temp_tables,omitempty"` // e.g. #orders or ##orders in SQL Server
+	TableVars  []string `json:"table_vars,omitempty"`  // e.g. @orders in SQL Server INSERT INTO @orders
+	TableHints []string `json:"table_hints,omitempty"` // e.g. NOLOCK in SQL Server FROM t WITH (NOLOCK)
+	QueryHints []string `json:"query_hints,omitempty"` // e.g. RECOMPILE in SQL Server OPTION (RECOMPILE)
+	// TableAccesses are the tables with the operation accessing them, only collected with WithCollectTableAccesses,
+	// e.g. audit written and users read by SELECT in INSERT INTO audit SELECT * FROM users
+	TableAccesses []TableAccess `json:"table_accesses,omitempty"`
+	CTEs          []string      `json:"ctes,omitempty"` // e.g. x in WITH x AS (...), only collected with WithCollectTableAccesses or WithCollectStructure
+	// TableRefs are the tables split into their parts, only collected with WithCollectTableRefs,
+	// e.g. {Schema: Sales, Name: Orders, Quoted: [true true]} for "Sales"."Orders"
+	TableRefs []TableRef `json:"table_refs,omitempty"`
+	// Columns are the columns referenced by the statement, only collected with WithCollectColumns,
+	// e.g. {Table: u, Name: email, Clause: WHERE} for WHERE u.email = ?
+	Columns []ColumnRef `json:"columns,omitempty"`
+	// Functions are the functions called by the statement, only collected with WithCollectFunctions
+	Functions []FunctionCall `json:"functions,omitempty"`
+	// Predicates are the predicates comparing a column in WHERE, ON and HAVING, only collected with WithCollectPredicates,
+	// e.g. {Column: u.email, Operator: =, Right: parameter, Clause: WHERE} for WHERE u.email = $1
+	Predicates []Predicate `json:"predicates,omitempty"`
+	// The structure of the statement, only collected with WithCollectStructure
+	RecursiveCTE         bool `json:"recursive_cte,omitempty"`          // WITH RECURSIVE was used
+	SubqueryDepth        int  `json:"subquery_depth,omitempty"`         // the maximum nesting of subqueries, e.g. 2 for WHERE a IN (SELECT ... WHERE b IN (SELECT ...))
+	SetOperationBranches int  `json:"set_operation_branches,omitempty"` // the queries combined by UNION, INTERSECT, EXCEPT or MINUS, e.g. 3 for A UNION B UNION C
+	Joins                int  `json:"joins,omitempty"`                  // the JOIN clauses, including CROSS APPLY and OUTER APPLY in SQL Server
+	// Databases and Schemas are the ones the statement runs against, only collected with WithCollectSchemas,
+	// e.g. sales in USE sales or sales.dbo.orders, and dbo in sales.dbo.orders or SET search_path TO dbo.
+	// Schemas are reported as databases in MySQL, e.g. shop in shop.orders.
+	Databases []string `json:"databases,omitempty"`
+	Schemas   []string `json:"schemas,omitempty"`
+}
+
+// The kinds of the right operand of a predicate
+const (
+	OperandLiteral    = "literal"    // e.g. 42, 'x', TRUE or DATE '2024-01-01'
+	OperandParameter  = "parameter"  // e.g. ?, $1, :name or @p1
+	OperandColumn     = "column"     // e.g. u.id in o.user_id = u.id
+	OperandSubquery   = "subquery"   // e.g. IN (SELECT ...)
+	OperandExpression = "expression" // anything else, e.g. NOW() or CASE ... END
+)
+
+// Predicate is a predicate comparing a column, e.g. o.total > ?
+type Predicate struct {
+	Column   string `json:"column"`          // the column on the left, qualified as written, e.g. o.total
+	Operator string `json:"operator"`        // the operator upper-cased, e.g. =, >=, NOT IN, LIKE, BETWEEN or IS NOT NULL
+	Right    string `json:"right,omitempty"` // the kind of the right operand, one of the Operand constants, empty for IS [NOT] NULL
+	Clause   string `json:"clause"`          // ClauseWhere, ClauseJoin or ClauseHaving
+}
+
+// FunctionCall is a function called by a statement, with the number of times the statement calls it
+type FunctionCall struct {
+	Name             string `json:"name"`                        // the name as written, schema-qualified, folded the way the dialect folds unquoted identifiers
+	BuiltIn          bool   `json:"built_in"`                    // false for user-defined functions
+	NonDeterministic bool   `json:"non_deterministic,omitempty"` // the built-in returns a different result for the same arguments, e.g. NOW() or RAND()
+	Count            int    `json:"count"`
+}
+
+// The clauses referencing columns
+const (
+	ClauseSelect  = "SELECT"   // the select list
+	ClauseWhere   = "WHERE"    // WHERE conditions
+	ClauseJoin    = "JOIN"     // join conditions, e.g. JOIN t ON ... or JOIN t USING (...)
+	ClauseGroupBy = "GROUP BY" // grouping expressions
+	ClauseHaving  = "HAVING"   // HAVING conditions
+	ClauseOrderBy = "ORDER BY" // sort keys
+	ClauseInsert  = "INSERT"   // the column list of INSERT INTO t (...)
+	ClauseSet     = "SET"      // the columns assigned by UPDATE t SET ...
+)
+
+// ColumnRef is a column referenced by a statement
+type ColumnRef struct {
+	Table  string `json:"table,omitempty"` // the table or alias qualifying the column, e.g. u in u.email
+	Name   string `json:"name"`
+	Clause string `json:"clause"` // one of the Clause constants
+}
+
+// TableRef is a table name split into its parts at the dots outside of quotes
+type TableRef struct {
+	Catalog string `json:"catalog,omitempty"` // e.g. db in db.dbo.orders
+	Schema  string `json:"schema,omitempty"`  // e.g. dbo in db.dbo.orders
+	Name    string `json:"name"`
+	// Quoted tells for each written part, from the first one to Name, whether it is quoted,
+	// e.g. [false true] for sales."Orders"
+	Quoted []bool `json:"quoted"`
+}
+
+const (
+	TableAccessRead  = "read"
+	TableAccessWrite = "write"
+)
+
+// TableAccess is a table accessed by a statement
+type TableAccess struct {
+	Name      string `json:"name"`
+	Operation string `json:"operation"` // SELECT, INSERT, UPDATE, DELETE, MERGE or DDL
+	Role      string `json:"role"`      // TableAccessRead or TableAccessWrite
+}
+
+type metadataSet struct {
+	size          int
+	tablesSet     map[string]struct{}
+	commentsSet   map[string]struct{}
+	commandsSet   map[string]struct{}
+	proceduresSet map[string]struct{}
+	indexHintsSet map[string]struct{}
+	filePathsSet  map[string]struct{}
+	lockHintsSet  map[string]struct{}
+	sequencesSet  map[string]struct{}
+	stagesSet     map[string]struct{}
+	dbLinksSet    map[string]struct{}
+	packagesSet   map[string]struct{}
+	tempTablesSet map[string]struct{}
+	tableVarsSet  map[string]struct{}
+	tableHintsSet map[string]struct{}
+	queryHintsSet map[string]struct{}
+	accessesSet   map[TableAccess]struct{}
+	ctesSet       map[string]struct{}
+	tableRefsSet  map[string]struct{}
+	columnsSet    map[ColumnRef]struct{}
+	functionsSet  map[string]int // index of each function in StatementMetadata.Functions
+	predicatesSet map[Predicate]struct{}
+	databasesSet  map[string]struct{}
+	schemasSet    map[string]struct{}
+}
+
+// newMetadataSet returns the sets of the metadata collected by default,
+// the sets of the other metadata are only allocated when the first value is added
+func newMetadataSet() *metadataSet {
+	return &metadataSet{
+		tablesSet:     map[string]struct{}{},
+		commentsSet:   map[string]struct{}{},
+		commandsSet:   map[string]struct{}{},
+		proceduresSet: map[string]struct{}{},
+	}
+}
+
+func newStatementMetadata() *StatementMetadata {
+	return &StatementMetadata{
+		Tables:     []string{},
+		Comments:   []string{},
+		Commands:   []string{},
+		Procedures: []string{},
+	}
+}
+
+// addMetadata adds a value to a metadata slice if it doesn't exist in the set
+func (m *metadataSet) addMetadata(value string, set *map[string]struct{}, slice *[]string) {
+	if *set == nil {
+		*set = map[string]struct{}{}
+	}
+	if _, exists := (*set)[value]; !exists {
+		(*set)[value] = struct{}{}
+		*slice = append(*slice, value)
+		m.size += len(value)
+	}
+}
+
+// addTableAccess adds a table access if it doesn't exist in the set.
+// The table name is already counted in the size of the tables.
+func (m *metadataSet) addTableAccess(access TableAccess, statementMetadata *StatementMetadata) {
+	if m.accessesSet == nil {
+		m.accessesSet = map[TableAccess]struct{}{}
+	}
+	if _, exists := m.accessesSet[access]; !exists {
+		m.accessesSet[access] = struct{}{}
+		statementMetadata.TableAccesses = append(statementMetadata.TableAccesses, access)
+	}
+}
+
+// merge adds the metadata of a nested statement, e.g. SQL held in a string literal
+func (m *metadataSet) merge(statementMetadata *StatementMetadata, nested *StatementMetadata) {
+	m.mergeMetadata(nested.Tables, &m.tablesSet, &statementMetadata.Tables)
+	m.mergeMetadata(nested.Comments, &m.commentsSet, &statementMetadata.Comments)
+	m.mergeMetadata(nested.Commands, &m.commandsSet, &statementMetadata.Commands)
+	m.mergeMetadata(nested.Procedures, &m.proceduresSet, &statementMetadata.Procedures)
+	m.mergeMetadata(nested.IndexHints, &m.indexHintsSet, &statementMetadata.IndexHints)
+	m.mergeMetadata(nested.FilePaths, &m.filePathsSet, &statementMetadata.FilePaths)
+	m.mergeMetadata(nested.LockHints, &m.lockHintsSet, &statementMetadata.LockHints)
+	m.mergeMetadata(nested.Sequences, &m.sequencesSet, &statementMetadata.Sequences)
+	m.mergeMetadata(nested.Stages, &m.stagesSet, &statementMetadata.Stages)
+	m.mergeMetadata(nested.DBLinks, &m.dbLinksSet, &statementMetadata.DBLinks)
+	m.mergeMetadata(nested.Packages, &m.packagesSet, &statementMetadata.Packages)
+	m.mergeMetadata(nested.TempTables, &m.tempTablesSet, &statementMetadata.TempTables)
+	m.mergeMetadata(nested.TableVars, &m.tableVarsSet, &statementMetadata.TableVars)
+	m.mergeMetadata(nested.TableHints, &m.tableHintsSet, &statementMetadata.TableHints)
+	m.mergeMetadata(nested.QueryHints, &m.queryHintsSet, &statementMetadata.QueryHints)
+	m.mergeMetadata(nested.CTEs, &m.ctesSet, &statementMetadata.CTEs)
+	m.mergeMetadata(nested.Databases, &m.databasesSet, &statementMetadata.Databases)
+	m.mergeMetadata(nested.Schemas, &m.schemasSet, &statementMetadata.Schemas)
+	for _, access := range nested.TableAccesses {
+		m.addTableAccess(access, statementMetadata)
+	}
+	for _, ref := range nested.TableRefs {
+		m.addTableRef(ref, statementMetadata)
+	}
+	for _, column := range nested.Columns {
+		m.addColumn(column, statementMetadata)
+	}
+	for _, function := range nested.Functions {
+		m.addFunction(function, statementMetadata)
+	}
+	for _, predicate := range nested.Predicates {
+		m.addPredicate(predicate, statementMetadata)
+	}
+	statementMetadata.RecursiveCTE = statementMetadata.RecursiveCTE || nested.RecursiveCTE
+	statementMetadata.SubqueryDepth = max(statementMetadata.SubqueryDepth, nested.SubqueryDepth)
+	statementMetadata.SetOperationBranches += nested.SetOperationBranches
+	statementMetadata.Joins += nested.Joins
+}
+
+// mergeMetadata adds the values of a nested statement to a metadata slice if they don't exist in the set
+func (m *metadataSet) mergeMetadata(values []string, set *map[string]struct{}, slice *[]string) {
+	for _, value := range values {
+		m.addMetadata(value, set, slice)
+	}
+}
+
+// addPredicate adds a predicate if it doesn't exist in the set
+func (m *metadataSet) addPredicate(predicate Predicate, statementMetadata *StatementMetadata) {
+	if m.predicatesSet == nil {
+		m.predicatesSet = map[Predicate]struct{}{}
+	}
+	if _, exists := m.predicatesSet[predicate]; !exists {
+		m.predicatesSet[predicate] = struct{}{}
+		statementMetadata.Predicates = append(statementMetadata.Predicates, predicate)
+	}
+}
+
+// addFunction adds a function call, or adds its count to the count of the same function
+func (m *metadataSet) addFunction(function FunctionCall, statementMetadata *StatementMetadata) {
+	if m.functionsSet == nil {
+		m.functionsSet = map[string]int{}
+	}
+	if i, exists := m.functionsSet[function.Name]; exists {
+		statementMetadata.Functions[i].Count += function.Count
+		return
+	}
+	m.functionsSet[function.Name] = len(statementMetadata.Functions)
+	statementMetadata.Functions = append(statementMetadata.Functions, function)
+}
+
+// addColumn adds a column reference if it doesn't exist in the set
+func (m *metadataSet) addColumn(column ColumnRef, statementMetadata *StatementMetadata) {
+	if m.columnsSet == nil {
+		m.columnsSet = map[ColumnRef]struct{}{}
+	}
+	if _, exists := m.columnsSet[column]; !exists {
+		m.columnsSet[column] = struct{}{}
+		statementMetadata.Columns = append(statementMetadata.Columns, column)
+	}
+}
+
+// addTableRef adds a table reference if it doesn't exist in the set.
+// The table name is already counted in the size of the tables.
+func (m *metadataSet) addTableRef(ref TableRef, statementMetadata *StatementMetadata) {
+	key := ref.key()
+	if m.tableRefsSet == nil {
+		m.tableRefsSet = map[string]struct{}{}
+	}
+	if _, exists := m.tableRefsSet[key]; !exists {
+		m.tableRefsSet[key] = struct{}{}
+		statementMetadata.TableRefs = append(statementMetadata.TableRefs, ref)
+	}
+}
+
+// metadataState holds the context carried across tokens while collecting metadata
+type metadataState struct {
+	dbms       DBMSType
+	ctes       map[string]bool
+	inLockHint bool          // true while reading a lock hint, e.g. LOCKING ROW FOR ACCESS
+	lockHint   []string      // the words of the lock hint read so far
+	sequence   sequenceStage // the tokens read so far of a sequence reference, e.g. NEXT VALUE FOR
+	plsql      plsqlState
+	sqlServer  sqlServerState
+	tables     tableRefState
+	columns    columnState
+	predicate  predicateState
+	structure  structureState
+	schema     schemaState
+}
+
+// schemaState tracks the statements selecting a database or a schema, e.g. USE db or SET search_path TO a, b
+type schemaState struct {
+	stage  int
+	schema bool // the names are schemas rather than databases, e.g. in USE SCHEMA s in Snowflake
+}
+
+const (
+	schemaIdle   = iota
+	schemaUse    // USE was read, e.g. USE db or USE DATABASE db
+	schemaSet    // SET was read, the setting comes next, e.g. search_path
+	schemaAssign // the setting was read, TO or = comes next
+	schemaList   // the names separated by commas come next
+)
+
+// structureState tracks the parentheses of a statement to measure its subquery depth and its set operations
+type structureState struct {
+	parens   []structureParen
+	depth    int  // the number of open subqueries
+	setOps   int  // the set operators outside of parentheses
+	cteList  bool // true inside the list of CTEs of a WITH clause
+	cteLevel int  // the number of open parentheses around the WITH clause
+}
+
+type structureParen struct {
+	subquery bool // the parenthesis opens a subquery, e.g. IN (SELECT ...), rather than a CTE body or a list
+	cteBody  bool // the parenthesis opens the body of a CTE, e.g. WITH x AS (SELECT ...)
+	setOps   int  // the set operators inside the parenthesis
+}
+
+// columnState tracks the clause of each open parenthesis to tell which clause references a column
+type columnState struct {
+	clauses       []columnClause
+	pending       *ColumnRef // the last column, collected once the next token tells it is not a qualifier, e.g. "t" in "t".*
+	setTargetNext bool       // the next identifier is assigned by SET, e.g. a in SET a = b + 1
+	insertColumns bool       // the next parenthesis opens the column list of INSERT INTO t (...)
+	top           bool       // the select list follows TOP n in SQL Server, e.g. a in SELECT TOP 10 a
+	star          bool       // the last value tokens are a wildcard, optionally followed by star modifiers
+	starModifier  bool       // the last value token is a star modifier, e.g. EXCLUDE in * EXCLUDE (a) in DuckDB
+}
+
+// predicateState tracks the predicate being read, from its left column to its right operand
+type predicateState struct {
+	stage    int
+	column   string // the left column, e.g. o.total
+	clause   string
+	operator string // the operator read so far, e.g. IS NOT
+	prefix   bool   // the prefix of a named parameter was read, e.g. : in :name
+	cast     bool   // the left column is cast, e.g. a::int in PostgreSQL
+	// literalPrefix is the kind of the right operand read last if it turns out not to prefix a typed literal,
+	// e.g. column for date in a = date but not in a = DATE '2024-01-01', set until the next token tells them apart
+	literalPrefix string
+}
+
+const (
+	predicateIdle     = iota
+	predicateColumn   // the left column was read
+	predicateOperator // the operator was read, the right operand comes next
+	predicateList     // the parenthesis opening a list or a subquery was read, e.g. IN (
+)
+
+type columnClause struct {
+	depth    int
+	clause   string // the clause, or empty where identifiers are not columns, e.g. in FROM or VALUES
+	function string // the function called with the parenthesis, e.g. EXTRACT
+	joined   bool   // a JOIN was seen at this depth, so ON starts a join condition
+	// starModifier is true for the parenthesis of a star modifier, e.g. * EXCLUDE (a) in DuckDB,
+	// which names the columns of the wildcard rather than referencing columns
+	starModifier bool
+}
+
+// tableRefState tracks the table references of a statement across commas and parentheses,
+// e.g. FROM a, (SELECT ...) s, b or WITH x AS (...), y AS (...)
+type tableRefState struct {
+	depth       int            // depth of open parentheses
+	lists       []int          // depths of the open table lists, e.g. FROM a, b or DELETE FROM t USING a, b
+	writeList   bool           // the table list at the current depth lists written tables, e.g. UPDATE a, b SET ... in MySQL
+	commands    []depthCommand // the last command of each open depth, e.g. SELECT in INSERT INTO t (SELECT ...)
+	cteNameNext bool           // the next identifier names a CTE, e.g. after WITH or the comma between two CTEs
+	cteListNext bool           // the WITH clause starts a statement or a subquery, so its CTEs are separated by commas
+	inWith      bool           // true inside the list of CTEs of a WITH clause
+	withDepth   int            // depth of the WITH clause
+	// writeTarget is true when the current token is a written table, e.g. t in INSERT INTO t or DELETE FROM t
+	writeTarget     bool
+	writeTargetNext bool
+	deleteFromNext  bool // the next FROM of a DELETE names the deleted table, e.g. DELETE t FROM t JOIN u in SQL Server
+}
+
+type depthCommand struct {
+	depth   int
+	command string
+}
+
+// sqlServerState tracks the hints and cursors of SQL Server statements
+type sqlServerState struct {
+	inFetch          bool // FETCH ... FROM cursor INTO @var reads a cursor into variables
+	valuesSinceTable int  // the number of value tokens since the last table, -1 if there is none
+	hintsNext        bool // the next opening parenthesis starts a list of hints, e.g. after WITH or OPTION
+	inHints          bool // true while reading the hints of WITH (...) or OPTION (...)
+	queryHints       bool // the hints are query hints, e.g. OPTION (RECOMPILE), rather than table hints
+	hintDepth        int
+	hint             strings.Builder
+}
+
+// plsqlState tracks the statements of Oracle PL/SQL blocks, e.g. BEGIN pkg.proc(1); END;
+type plsqlState struct {
+	blockDepth       int    // nesting of BEGIN ... END blocks
+	caseDepth        int    // nesting of CASE ... END inside blocks
+	afterEnd         bool   // the last value token is END
+	blockEndPending  bool   // END closes a block unless it is END IF, END LOOP or END CASE
+	statementStart   bool   // the next value token starts a statement of a block
+	statementCommand string // the first word of the current statement of a block
+	procedureNext    bool   // the next identifier is a called procedure, e.g. after EXEC
+	procedureCall    bool   // the last value token is a procedure called as a statement, e.g. pkg.proc in pkg.proc(1);
+}
+
+type groupablePlaceholder struct {
+	groupable bool
+	inBraces  bool     // true if the placeholders are in a collection literal, e.g. {'k': 'v'}
+	dbms      DBMSType // collection literals are only grouped in Cassandra
+}
+
+type headState struct {
+	readFirstNonSpaceNonComment         bool
+	inLeadingParenthesesExpression      bool
+	foundLeadingExpressionInParentheses bool
+	standaloneExpressionInParentheses   bool
+	expressionInParentheses             strings.Builder
+}
+
+type Normalizer struct {
+	config *normalizerConfig
+}
+
+func NewNormalizer(opts ...normalizerOption) *Normalizer {
+	normalizer := Normalizer{
+		config: &normalizerConfig{},
+	}
+
+	for _, opt := range opts {
+		opt(normalizer.config)
+	}
+
+	return &normalizer
+}
+
+// normalizeToken is a helper function that handles the common normalization logic
+func (n *Normalizer) normalizeToken(lexer *Lexer, normalizedSQLBuilder *strings.Builder, meta *metadataSet, statementMetadata *StatementMetadata, preProcessToken func(*Token, *LastValueToken), lexerOpts ...lexerOption) error {
+	groupablePlaceholder := groupablePlaceholder{dbms: lexer.config.DBMS}
+	var headState headState
+	metadataState := metadataState{dbms: lexer.config.DBMS}
+	metadataState.sqlServer.valuesSinceTable = -1
+
+	var lastValueToken *LastValueToken
+
+	for {
+		token := lexer.Scan()
+		if token.Type == PUNCTUATION && lexer.isDelimiter(token.Value) {
+			// statement delimiter set by a DELIMITER directive, e.g. $$ in MySQL scripts
+			token.Value = ";"
+		}
+		if preProcessToken != nil {
+			// pre-process the token, often used for obfuscation
+			preProcessToken(token, lastValueToken)
+		}
+		if n.config.FoldIdentifierCaseInOutput && (token.Type == IDENT || token.Type == QUOTED_IDENT || token.Type == FUNCTION) {
+			token.Value = foldIdentifierCase(token.Value, token.quotes, lexer.config.DBMS)
+		}
+		if token.Type == EMBEDDED_SQL {
+			if !n.config.NormalizeEmbeddedSQL {
+				// read as an ordinary string literal, e.g. when only the obfuscator reads the SQL statement
+				token.Type = STRING
+			} else if token.Value != StringPlaceholder {
+				// recursively normalize the SQL statement held by the string literal
+				n.normalizeEmbeddedSQL(token, meta, statementMetadata, lexerOpts...)
+			}
+		}
+		if n.shouldCollectMetadata() {
+			n.collectMetadata(token, lastValueToken, meta, statementMetadata, &metadataState)
+		}
+		n.normalizeSQL(token, lastValueToken, normalizedSQLBuilder, &groupablePlaceholder, &headState, lexerOpts...)
+		if token.Type == EOF {
+			break
+		}
+		if isValueToken(token) {
+			lastValueToken = token.getLastValueToken()
+		} else if token.Type == CLIENT_DIRECTIVE && lastValueToken != nil && lastValueToken.Value != ";" {
+			// a directive ends the current statement, e.g. GO in sqlcmd,
+			// so that the statements before and after it are not joined
+			separator := &Token{Type: PUNCTUATION, Value: ";"}
+			if n.shouldCollectMetadata() {
+				n.collectMetadata(separator, lastValueToken, meta, statementMetadata, &metadataState)
+			}
+			n.normalizeSQL(separator, lastValueToken, normalizedSQLBuilder, &groupablePlaceholder, &headState, lexerOpts...)
+			lastValueToken = separator.getLastValueToken()
+		}
+	}
+
+	return nil
+}
+
+func (n *Normalizer) Normalize(input string, lexerOpts ...lexerOption) (normalizedSQL string, statementMetadata *StatementMetadata, err error) {
+	lexer := New(input, lexerOpts...)
+	lexer.embeddedSQL.enabled = n.config.NormalizeEmbeddedSQL
+	var normalizedSQLBuilder strings.Builder
+	normalizedSQLBuilder.Grow(len(input))
+
+	meta := newMetadataSet()
+	statementMetadata = newStatementMetadata()
+
+	if err = n.normalizeToken(lexer, &normalizedSQLBuilder, meta, statementMetadata, nil, lexerOpts...); err != nil {
+		return "", nil, err
+	}
+
+	normalizedSQL = normalizedSQLBuilder.String()
+	statementMetadata.Size = meta.size
+	return n.trimNormalizedSQL(normalizedSQL), statementMetadata, nil
+}
+
+// NormalizedStatement is the normalized SQL and metadata of a single statement of a multi-statement input
+type NormalizedStatement struct {
+	Statement                            // the statement and its byte range in the input
+	NormalizedSQL     string             // the normalized statement
+	StatementMetadata *StatementMetadata // the metadata of the statement only
+}
+
+// MultiStatementResult holds the results of normalizing a multi-statement input
+type MultiStatementResult struct {
+	Statements []NormalizedStatement // one result per statement, in input order
+	Merged     *NormalizedStatement  // merged view of the whole input, only set with WithMergeStatements
+}
+
+// NormalizeMulti splits the input into its statements with SplitStatements and normalizes each statement separately,
+// so that the metadata of a statement, e.g. the tables it writes, is not mixed with the metadata of the others
+func (n *Normalizer) NormalizeMulti(input string, lexerOpts ...lexerOption) (*MultiStatementResult, error) {
+	return n.normalizeMulti(input, func(statement string) (string, *StatementMetadata, error) {
+		return n.Normalize(statement, lexerOpts...)
+	}, lexerOpts...)
+}
+
+func (n *Normalizer) normalizeMulti(input string, normalize func(string) (string, *StatementMetadata, error), lexerOpts ...lexerOption) (*MultiStatementResult, error) {
+	statements := SplitStatements(input, lexerOpts...)
+	result := &MultiStatementResult{Statements: make([]NormalizedStatement, 0, len(statements))}
+
+	for _, statement := range statements {
+		normalizedSQL, statementMetadata, err := normalize(statement.Text)
+		if err != nil {
+			return nil, err
+		}
+		if n.config.KeepTrailingSemicolon && !strings.HasSuffix(normalizedSQL, ";") {
+			// the statement text stops before its terminator
+			normalizedSQL += ";"
+		}
+		result.Statements = append(result.Statements, NormalizedStatement{
+			Statement:         statement,
+			NormalizedSQL:     normalizedSQL,
+			StatementMetadata: statementMetadata,
+		})
+	}
+
+	if n.config.MergeStatements {
+		result.Merged = mergeNormalizedStatements(input, result.Statements)
+	}
+	return result, nil
+}
+
+// mergeNormalizedStatements joins the normalized statements and combines their metadata
+func mergeNormalizedStatements(input string, statements []NormalizedStatement) *NormalizedStatement {
+	var normalizedSQLBuilder strings.Builder
+	meta := newMetadataSet()
+	statementMetadata := newStatementMetadata()
+
+	for i, statement := range statements {
+		if i > 0 {
+			if !strings.HasSuffix(statements[i-1].NormalizedSQL, ";") {
+				normalizedSQLBuilder.WriteString(";")
+			}
+			normalizedSQLBuilder.WriteString(" ")
+		}
+		normalizedSQLBuilder.WriteString(statement.NormalizedSQL)
+		meta.merge(statementMetadata, statement.StatementMetadata)
+	}
+	statementMetadata.Size = meta.size
+
+	return &NormalizedStatement{
+		Statement:         Statement{Text: input, Start: 0, End: len(input)},
+		NormalizedSQL:     normalizedSQLBuilder.String(),
+		StatementMetadata: statementMetadata,
+	}
+}
+
+func (n *Normalizer) shouldCollectMetadata() bool {
+	return n.config.CollectTables || n.config.CollectCommands || n.config.CollectComments || n.config.CollectProcedure || n.config.CollectColumns || n.config.CollectFunctions || n.config.CollectPredicates || n.config.CollectStructure || n.config.CollectSchemas
+}
+
+// tracksTableReferences checks if the metadata collected needs the table references tracked by collectTableReference,
+// e.g. to tell CTEs from tables or to know the command of the current subquery
+func (n *Normalizer) tracksTableReferences() bool {
+	return n.config.CollectTables || n.config.CollectColumns || n.config.CollectPredicates || n.config.CollectStructure
+}
+
+func (n *Normalizer) collectMetadata(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	if state.dbms == DBMSOracle && (n.config.CollectProcedure || n.config.CollectFunctions || n.tracksTableReferences()) {
+		n.collectPLSQLStatement(token, meta, statementMetadata, state)
+	}
+	if n.config.CollectTables && state.dbms == DBMSSQLServer {
+		n.collectSQLServerHints(token, lastValueToken, meta, statementMetadata, state)
+	}
+	if n.config.CollectTables && state.dbms == DBMSTeradata {
+		n.collectLockHint(token, lastValueToken, meta, statementMetadata, state)
+	}
+	isSequence, isCTEName := false, false
+	if n.config.CollectTables {
+		isSequence = n.collectSequence(token, meta, statementMetadata, state)
+	}
+	if n.tracksTableReferences() {
+		isCTEName = n.collectTableReference(token, lastValueToken, state)
+	}
+	if n.config.CollectStructure {
+		collectStructure(token, lastValueToken, statementMetadata, state)
+	}
+	if n.config.CollectSchemas {
+		n.collectSchemas(token, lastValueToken, meta, statementMetadata, state)
+	}
+	if n.config.CollectColumns || n.config.CollectPredicates {
+		n.collectClauses(token, lastValueToken, meta, statementMetadata, state)
+	}
+	if n.config.CollectFunctions && token.Type == FUNCTION {
+		collectFunction(token, lastValueToken, meta, statementMetadata, state)
+	}
+
+	if n.config.CollectComments && (token.Type == COMMENT || token.Type == MULTILINE_COMMENT) {
+		comment := token.Value
+		meta.addMetadata(comment, &meta.commentsSet, &statementMetadata.Comments)
+	} else if token.Type == INDEX_HINT {
+		if n.config.CollectTables {
+			meta.addMetadata(trimIndexHint(token.Value), &meta.indexHintsSet, &statementMetadata.IndexHints)
+		}
+	} else if token.Type == DB_LINK {
+		if n.config.CollectTables {
+			meta.addMetadata(token.Value[1:], &meta.dbLinksSet, &statementMetadata.DBLinks)
+		}
+	} else if token.Type == STAGE {
+		if n.config.CollectTables {
+			meta.addMetadata(trimStagePath(token.Value), &meta.stagesSet, &statementMetadata.Stages)
+		}
+	} else if token.Type == FILE_PATH {
+		// obfuscated file paths end with the placeholder rather than the closing quote
+		if n.config.CollectTables && !strings.HasSuffix(token.Value, StringPlaceholder) {
+			meta.addMetadata(trimFilePath(token), &meta.filePathsSet, &statementMetadata.FilePaths)
+		}
+	} else if token.Type == COMMAND {
+		if n.config.CollectCommands {
+			command := canonicalCommand(token.Value, state.dbms)
+			meta.addMetadata(command, &meta.commandsSet, &statementMetadata.Commands)
+		}
+	} else if state.dbms == DBMSTrino && token.Value == "(" && lastValueToken != nil && lastValueToken.Type == KEYWORD && strings.EqualFold(lastValueToken.Value, "TABLE") {
+		// Trino table arguments, e.g. exclude_columns(input => TABLE(orders), ...)
+		token.isTableIndicator = true
+	} else if state.dbms == DBMSSnowflake && token.Value == "(" && lastValueToken != nil && lastValueToken.Type == FUNCTION && lastValueToken.isTableIndicator {
+		// Snowflake object names passed as strings, e.g. FROM IDENTIFIER('db.t')
+		token.isTableIndicator = true
+	} else if token.Type == BIND_PARAMETER && state.dbms == DBMSSQLServer && lastValueToken != nil && lastValueToken.isTableIndicator {
+		// table variables, e.g. INSERT INTO @orders, but not FETCH NEXT FROM cursor INTO @id
+		if n.config.CollectTables && !state.sqlServer.inFetch && strings.HasPrefix(token.Value, "@") {
+			meta.addMetadata(token.Value, &meta.tableVarsSet, &statementMetadata.TableVars)
+			state.sqlServer.valuesSinceTable = 0
+		}
+	} else if token.Type == IDENT || token.Type == QUOTED_IDENT || token.Type == FUNCTION {
+		// the table reference is split from the token as written, before the quotes are trimmed
+		rawVal, quotes := token.Value, token.quotes
+		tokenVal := token.Value
+		if token.Type == QUOTED_IDENT {
+			tokenVal = trimQuotes(token)
+			if !n.config.KeepIdentifierQuotation {
+				// trim quotes and set the token type to IDENT
+				token.Value = tokenVal
+				token.Type = IDENT
+			}
+		}
+		if n.config.FoldIdentifierCase {
+			rawVal = foldIdentifierCase(rawVal, quotes, state.dbms)
+			folded := Token{Value: rawVal, quotes: quotes}
+			tokenVal = trimQuotes(&folded)
+		}
+		if isCTEName {
+			// only allocate the CTEs map once a statement names one
+			if state.ctes == nil {
+				state.ctes = make(map[string]bool, 2)
+			}
+			state.ctes[tokenVal] = true
+			if n.config.CollectTableAccesses || n.config.CollectStructure {
+				meta.addMetadata(tokenVal, &meta.ctesSet, &statementMetadata.CTEs)
+			}
+		} else if n.config.CollectTables && lastValueToken != nil && lastValueToken.isTableIndicator {
+			if isSequence || isTableFunction(token, state.dbms) {
+				return
+			}
+			if token.Type == IDENT && strings.EqualFold(tokenVal, "LATERAL") {
+				// LATERAL is followed by a subquery or a table function rather than a table
+				return
+			}
+			if state.dbms == DBMSSnowflake && token.Type == FUNCTION && strings.EqualFold(tokenVal, "IDENTIFIER") {
+				// the table is the argument of IDENTIFIER(...)
+				token.isTableIndicator = true
+				return
+			}
+			if state.dbms == DBMSSQLServer {
+				if state.sqlServer.inFetch {
+					// FETCH NEXT FROM cursor reads a cursor rather than a table
+					return
+				}
+				if !strings.EqualFold(lastValueToken.Value, "TABLE") {
+					// CREATE TABLE t WITH (...) sets table options rather than table hints
+					state.sqlServer.valuesSinceTable = 0
+				}
+				if strings.HasPrefix(tokenVal, "#") {
+					// local #orders or global ##orders temporary tables
+					meta.addMetadata(tokenVal, &meta.tempTablesSet, &statementMetadata.TempTables)
+					return
+				}
+			}
+			if _, ok := state.ctes[tokenVal]; !ok {
+				meta.addMetadata(tokenVal, &meta.tablesSet, &statementMetadata.Tables)
+				if n.config.CollectTableAccesses {
+					meta.addTableAccess(state.tables.tableAccess(tokenVal), statementMetadata)
+				}
+				if n.config.CollectTableRefs || n.config.CollectSchemas {
+					ref := newTableRef(rawVal, quotes)
+					if n.config.CollectTableRefs {
+						meta.addTableRef(ref, statementMetadata)
+					}
+					if n.config.CollectSchemas && ref.Catalog != "" {
+						meta.addMetadata(ref.Catalog, &meta.databasesSet, &statementMetadata.Databases)
+					}
+					if n.config.CollectSchemas && ref.Schema != "" {
+						if isMySQLFamily(state.dbms) {
+							// a schema is a database in MySQL, e.g. shop in shop.orders
+							meta.addMetadata(ref.Schema, &meta.databasesSet, &statementMetadata.Databases)
+						} else {
+							meta.addMetadata(ref.Schema, &meta.schemasSet, &statementMetadata.Schemas)
+						}
+					}
+				}
+			}
+		} else if n.config.CollectProcedure && lastValueToken != nil && lastValueToken.Type == PROC_INDICATOR {
+			meta.addMetadata(tokenVal, &meta.proceduresSet, &statementMetadata.Procedures)
+		}
+	}
+}
+
+// collectSQLServerHints collects SQL Server table hints, e.g. FROM t WITH (NOLOCK, INDEX(ix_a)),
+// and query hints, e.g. OPTION (RECOMPILE, MAXDOP 1), one entry per hint
+func (n *Normalizer) collectSQLServerHints(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	if !isValueToken(token) {
+		return
+	}
+	ss := &state.sqlServer
+	value := token.Value
+
+	if token.Type == COMMAND || value == ";" {
+		ss.inFetch = false
+	}
+	if strings.EqualFold(value, "FETCH") {
+		ss.inFetch = true
+	}
+
+	valuesSinceTable := ss.valuesSinceTable
+	if ss.valuesSinceTable >= 0 {
+		ss.valuesSinceTable++
+	}
+
+	if ss.inHints {
+		switch {
+		case value == "(":
+			ss.hintDepth++
+		case value == ")":
+			ss.hintDepth--
+		}
+		if ss.hintDepth == 0 || (ss.hintDepth == 1 && value == ",") {
+			// end of a hint
+			if ss.hint.Len() > 0 {
+				if ss.queryHints {
+					meta.addMetadata(ss.hint.String(), &meta.queryHintsSet, &statementMetadata.QueryHints)
+				} else {
+					meta.addMetadata(ss.hint.String(), &meta.tableHintsSet, &statementMetadata.TableHints)
+				}
+				ss.hint.Reset()
+			}
+			ss.inHints = ss.hintDepth > 0
+			return
+		}
+		if ss.hintDepth == 1 && value == "(" {
+			// the opening parenthesis of the hint list
+			return
+		}
+		appendHintToken(&ss.hint, token, lastValueToken, ss.hintDepth == 1)
+		return
+	}
+
+	if ss.hintsNext {
+		if value == "(" {
+			ss.inHints = true
+			ss.hintDepth = 1
+		}
+		ss.hintsNext = false
+		return
+	}
+
+	switch {
+	case strings.EqualFold(value, "WITH") && valuesSinceTable >= 0 && valuesSinceTable <= 2:
+		// WITH follows the table or its alias, e.g. FROM t AS a WITH (NOLOCK)
+		ss.hintsNext, ss.queryHints = true, false
+	case strings.EqualFold(value, "OPTION"):
+		ss.hintsNext, ss.queryHints = true, true
+	}
+}
+
+// normalizeEmbeddedSQL normalizes the SQL statement held by a string literal, e.g. EXECUTE IMMEDIATE '...',
+// and adds its metadata to the metadata of the outer statement
+func (n *Normalizer) normalizeEmbeddedSQL(token *Token, meta *metadataSet, statementMetadata *StatementMetadata, lexerOpts ...lexerOption) {
+	normalizedSQL, nestedMetadata, err := n.Normalize(unquoteEmbeddedSQL(token.Value), lexerOpts...)
+	if err != nil {
+		// if there is an error, we just keep the original content
+		return
+	}
+	token.Value = quoteEmbeddedSQL(normalizedSQL)
+	meta.merge(statementMetadata, nestedMetadata)
+}
+
+// collectLockHint collects Teradata lock hints, e.g. LOCKING ROW FOR ACCESS or LOCKING TABLE t FOR READ
+func (n *Normalizer) collectLockHint(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	if !isValueToken(token) {
+		return
+	}
+	if !state.inLockHint {
+		if strings.EqualFold(token.Value, "LOCKING") || strings.EqualFold(token.Value, "LOCK") {
+			state.inLockHint = true
+			state.lockHint = state.lockHint[:0]
+		}
+		return
+	}
+
+	word := token.Value
+	if token.Type == KEYWORD || token.Type == COMMAND || token.Type == IDENT && isTeradataLockWord(word) {
+		word = strings.ToUpper(word)
+	}
+	state.lockHint = append(state.lockHint, word)
+	if lastValueToken != nil && strings.EqualFold(lastValueToken.Value, "FOR") {
+		// the lock type follows FOR and ends the lock hint
+		state.inLockHint = false
+		meta.addMetadata(strings.Join(state.lockHint, " "), &meta.lockHintsSet, &statementMetadata.LockHints)
+	}
+}
+
+// collectPLSQLStatement tracks the statements of Oracle PL/SQL blocks so that the metadata of each
+// statement is collected on its own, e.g. SELECT ... INTO v reads into a variable rather than a table,
+// and collects the procedures called in blocks or with EXEC, e.g. BEGIN pkg.proc(1); END;
+// The block itself is still normalized as a single statement.
+func (n *Normalizer) collectPLSQLStatement(token *Token, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	if !isValueToken(token) {
+		return
+	}
+	p := &state.plsql
+	value := token.Value
+	p.procedureCall = false
+
+	if p.afterEnd {
+		p.afterEnd = false
+		if strings.EqualFold(value, "IF") || strings.EqualFold(value, "LOOP") || strings.EqualFold(value, "CASE") {
+			// END IF, END LOOP and END CASE do not close a block
+			p.blockEndPending = false
+			return
+		}
+	}
+	if p.blockEndPending {
+		p.blockEndPending = false
+		p.blockDepth--
+	}
+
+	if p.procedureNext {
+		p.procedureNext = false
+		if (token.Type == IDENT || token.Type == FUNCTION) && !strings.EqualFold(value, "IMMEDIATE") {
+			n.collectProcedureCall(n.metadataName(token, state.dbms), meta, statementMetadata)
+		}
+	}
+	if p.statementStart {
+		p.statementStart = false
+		p.statementCommand = value
+		if token.Type == FUNCTION && !strings.EqualFold(value, "RAISE_APPLICATION_ERROR") {
+			// procedure call statement, e.g. pkg.proc(1);
+			// RAISE_APPLICATION_ERROR raises an error rather than calling a procedure of the application
+			p.procedureCall = true
+			n.collectProcedureCall(n.metadataName(token, state.dbms), meta, statementMetadata)
+		}
+	}
+
+	switch {
+	case value == ";":
+		p.statementStart = p.blockDepth > 0
+	case token.Type != COMMAND && token.Type != KEYWORD && token.Type != IDENT:
+		// the keywords below are read as commands, keywords or identifiers
+	case strings.EqualFold(value, "BEGIN"):
+		if token.Type == COMMAND {
+			p.blockDepth++
+			p.statementStart = true
+		}
+	case strings.EqualFold(value, "END"):
+		if p.caseDepth > 0 {
+			p.caseDepth--
+		} else if p.blockDepth > 0 {
+			p.blockEndPending = true
+		}
+		p.afterEnd = true
+	case strings.EqualFold(value, "CASE"):
+		p.caseDepth++
+	case strings.EqualFold(value, "LOOP"):
+		p.statementStart = p.blockDepth > 0
+	case strings.EqualFold(value, "THEN"), strings.EqualFold(value, "ELSE"):
+		// THEN and ELSE of CASE expressions are followed by expressions rather than statements
+		p.statementStart = p.blockDepth > 0 && p.caseDepth == 0
+	case strings.EqualFold(value, "EXEC"), strings.EqualFold(value, "EXECUTE"), strings.EqualFold(value, "CALL"):
+		p.procedureNext = true
+	case strings.EqualFold(value, "INTO"):
+		if p.blockDepth > 0 && !strings.EqualFold(p.statementCommand, "INSERT") && !strings.EqualFold(p.statementCommand, "MERGE") {
+			// SELECT ... INTO, FETCH ... INTO and RETURNING ... INTO read into variables
+			token.isTableIndicator = false
+		}
+	}
+}
+
+// metadataName returns the name of an identifier collected as metadata, without its quotes
+// and folded with FoldIdentifierCase, leaving the token untouched
+func (n *Normalizer) metadataName(token *Token, dbms DBMSType) string {
+	// trim a copy, trimQuotes drops the quote indexes the token still needs
+	name := *token
+	if n.config.FoldIdentifierCase {
+		name.Value = foldIdentifierCase(name.Value, name.quotes, dbms)
+	}
+	if name.Type != QUOTED_IDENT {
+		return name.Value
+	}
+	return trimQuotes(&name)
+}
+
+// collectProcedureCall collects a called procedure, separating its package, e.g. billing.charge
+func (n *Normalizer) collectProcedureCall(name string, meta *metadataSet, statementMetadata *StatementMetadata) {
+	if !n.config.CollectProcedure {
+		return
+	}
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		meta.addMetadata(name[:i], &meta.packagesSet, &statementMetadata.Packages)
+		name = name[i+1:]
+	}
+	meta.addMetadata(name, &meta.proceduresSet, &statementMetadata.Procedures)
+}
+
+// collectTableReference marks the tokens followed by a table reference beyond the table indicators,
+// e.g. the commas of FROM a, b, c or USING in DELETE FROM t USING u and MERGE INTO t USING s.
+// It returns true if the token names a CTE, e.g. x and y in WITH x AS (...), y AS (...).
+func (n *Normalizer) collectTableReference(token *Token, lastValueToken *LastValueToken, state *metadataState) bool {
+	if !isValueToken(token) {
+		return false
+	}
+	t := &state.tables
+	t.writeTarget = t.writeTargetNext
+
+	isCTEName := false
+	if t.cteNameNext {
+		switch {
+		case token.Type == IDENT || token.Type == QUOTED_IDENT || token.Type == FUNCTION:
+			isCTEName = true
+			t.cteNameNext = false
+			if t.cteListNext {
+				t.inWith = true
+				t.withDepth = t.depth
+				t.cteListNext = false
+			}
+		case strings.EqualFold(token.Value, "RECURSIVE"):
+			// WITH RECURSIVE x AS (...)
+		default:
+			// not a CTE, e.g. WITH (NOLOCK) in SQL Server
+			t.cteNameNext = false
+			t.cteListNext = false
+		}
+	}
+
+	switch token.Type {
+	case CTE_INDICATOR:
+		// only a WITH starting a statement or a subquery names CTEs,
+		// e.g. not WITH ORDINALITY in UNNEST(a) WITH ORDINALITY or WITH (NOLOCK) in SQL Server
+		t.cteListNext = lastValueToken == nil || lastValueToken.Value == ";" || lastValueToken.Value == "(" ||
+			lastValueToken.Type == ALIAS_INDICATOR || lastValueToken.Type == COMMAND
+		t.cteNameNext = t.cteListNext
+	case PUNCTUATION:
+		switch token.Value {
+		case "(":
+			t.depth++
+		case ")":
+			if t.depth > 0 {
+				t.depth--
+			}
+			for len(t.lists) > 0 && t.lists[len(t.lists)-1] > t.depth {
+				t.lists = t.lists[:len(t.lists)-1]
+			}
+			for len(t.commands) > 0 && t.commands[len(t.commands)-1].depth > t.depth {
+				t.commands = t.commands[:len(t.commands)-1]
+			}
+			if t.inWith && t.withDepth > t.depth {
+				t.inWith = false
+			}
+		case ";":
+			*t = tableRefState{lists: t.lists[:0], commands: t.commands[:0]}
+		case ",":
+			if t.inWith && t.withDepth == t.depth {
+				t.cteNameNext = true
+			} else if t.inTableList() && state.dbms != DBMSPartiQL {
+				// FROM a, b, but not FROM orders o, o.items i unnesting a path in PartiQL
+				token.isTableIndicator = true
+			}
+		}
+	case COMMAND:
+		command := canonicalCommand(token.Value, state.dbms)
+		if command == "JOIN" || command == "STRAIGHT_JOIN" {
+			break
+		}
+		t.endTableList()
+		if t.inWith && t.withDepth == t.depth {
+			// the statement following the CTEs
+			t.inWith = false
+		}
+		t.setCommand(command)
+		t.deleteFromNext = t.command() == "DELETE"
+		if t.command() == "UPDATE" && isMySQLFamily(state.dbms) && (lastValueToken == nil || lastValueToken.Value == ";") {
+			// multiple-table UPDATE a, b SET ...
+			t.openTableList()
+			t.writeList = true
+		}
+	case KEYWORD, IDENT:
+		switch {
+		case strings.EqualFold(token.Value, "FROM"):
+			t.openTableList()
+		case strings.EqualFold(token.Value, "USING") && (t.command() == "DELETE" || t.command() == "MERGE"):
+			// DELETE FROM t USING u or MERGE INTO t USING s, but not JOIN u USING (id)
+			token.isTableIndicator = true
+			t.openTableList()
+		case t.inTableList() && isTableListEnd(token.Value):
+			t.endTableList()
+		}
+	}
+	if n.config.CollectTableAccesses {
+		t.writeTargetNext = t.isWriteIndicator(token)
+	}
+	return isCTEName
+}
+
+// isWriteIndicator checks if the table following a token is written by the statement,
+// e.g. INTO in INSERT INTO t, FROM in DELETE FROM t or TABLE in DROP TABLE t
+func (t *tableRefState) isWriteIndicator(token *Token) bool {
+	switch token.Type {
+	case PUNCTUATION:
+		// UPDATE a, b SET ... in MySQL
+		return token.Value == "," && t.writeList && t.inTableList()
+	case COMMAND, KEYWORD, IDENT:
+	default:
+		return false
+	}
+	command := t.command()
+	switch value := token.Value; {
+	case strings.EqualFold(value, "UPDATE"), strings.EqualFold(value, "MERGE"), strings.EqualFold(value, "UPSERT"),
+		strings.EqualFold(value, "INTO"), strings.EqualFold(value, "OVERWRITE"):
+		return true
+	case strings.EqualFold(value, "FROM"):
+		// DELETE FROM t or DELETE t FROM t JOIN u, but not the tables of DELETE FROM t USING u
+		isTarget := command == "DELETE" && t.deleteFromNext
+		t.deleteFromNext = false
+		return isTarget
+	case strings.EqualFold(value, "TABLE"), strings.EqualFold(value, "EXISTS"):
+		// CREATE TABLE t, DROP TABLE IF EXISTS t or INSERT OVERWRITE TABLE t in Spark SQL
+		return isDDLCommand(command) || command == "INSERT"
+	case strings.EqualFold(value, "ONLY"):
+		// UPDATE ONLY t or DELETE FROM ONLY t in PostgreSQL
+		return t.writeTarget
+	}
+	return false
+}
+
+// command returns the last command at the current depth
+func (t *tableRefState) command() string {
+	if n := len(t.commands); n > 0 && t.commands[n-1].depth == t.depth {
+		return t.commands[n-1].command
+	}
+	return ""
+}
+
+func (t *tableRefState) setCommand(command string) {
+	if n := len(t.commands); n > 0 && t.commands[n-1].depth == t.depth {
+		t.commands[n-1].command = command
+		return
+	}
+	t.commands = append(t.commands, depthCommand{depth: t.depth, command: command})
+}
+
+// inTableList checks if a table list is open at the current depth
+func (t *tableRefState) inTableList() bool {
+	return len(t.lists) > 0 && t.lists[len(t.lists)-1] == t.depth
+}
+
+func (t *tableRefState) openTableList() {
+	t.writeList = false
+	if !t.inTableList() {
+		t.lists = append(t.lists, t.depth)
+	}
+}
+
+func (t *tableRefState) endTableList() {
+	t.writeList = false
+	if t.inTableList() {
+		t.lists = t.lists[:len(t.lists)-1]
+	}
+}
+
+// tableAccess returns the access of a table collected at the current token
+func (t *tableRefState) tableAccess(name string) TableAccess {
+	command := t.command()
+	if t.writeTarget {
+		return TableAccess{Name: name, Operation: tableAccessOperation(command, true), Role: TableAccessWrite}
 	}
+	return TableAccess{Name: name, Operation: tableAccessOperation(command, false), Role: TableAccessRead}
 }
 
-func WithCollectComments(collectComments bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.CollectComments = collectComments
+// collectClauses tracks the clause of each token to collect the columns referenced by the statement and the predicates comparing them
+func (n *Normalizer) collectClauses(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	c := &state.columns
+	if c.pending != nil && (token.Type == EOF || isValueToken(token)) {
+		if !isColumnQualified(token, c.pending) {
+			meta.addColumn(*c.pending, statementMetadata)
+		}
+		c.pending = nil
+	}
+	isLiteral := false
+	if n.config.CollectPredicates && state.predicate.literalPrefix != "" && (token.Type == EOF || isValueToken(token)) {
+		isLiteral = collectLiteralPrefixOperand(token, meta, statementMetadata, state)
+	}
+	if !isValueToken(token) {
+		return
 	}
-}
 
-func WithKeepSQLAlias(keepSQLAlias bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.KeepSQLAlias = keepSQLAlias
+	clause, isColumn := c.advance(token, lastValueToken, state)
+	var column *ColumnRef
+	if isColumn {
+		column = n.columnRef(token, clause.clause, state)
+	}
+	if n.config.CollectPredicates && !isLiteral {
+		collectPredicate(token, column, meta, statementMetadata, state)
+	}
+	if n.config.CollectColumns {
+		c.pending = column
 	}
 }
 
-func WithUppercaseKeywords(uppercaseKeywords bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.UppercaseKeywords = uppercaseKeywords
+// advance tracks the clause of a token and tells if the token is a column of a clause referencing columns,
+// i.e. the select list, WHERE, join conditions, GROUP BY, HAVING, ORDER BY, the column list of INSERT or the assignments of SET
+func (c *columnState) advance(token *Token, lastValueToken *LastValueToken, state *metadataState) (*columnClause, bool) {
+	if len(c.clauses) == 0 {
+		c.clauses = append(c.clauses, columnClause{})
 	}
-}
+	current := &c.clauses[len(c.clauses)-1]
+	value := token.Value
+	afterTop := c.top
+	switch {
+	case strings.EqualFold(value, "TOP"):
+		c.top = true
+	case token.Type != NUMBER && value != "(" && value != ")":
+		c.top = false
+	}
+	afterStar := c.star
+	c.star = token.Type == WILDCARD
 
-func WithCollectProcedures(collectProcedure bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.CollectProcedure = collectProcedure
+	switch token.Type {
+	case PUNCTUATION:
+		switch value {
+		case "(":
+			clause := columnClause{depth: current.depth + 1, clause: current.clause}
+			if lastValueToken != nil && (lastValueToken.Type == FUNCTION || isWindowKeyword(lastValueToken.Value)) {
+				clause.function = lastValueToken.Value
+			}
+			if c.insertColumns {
+				clause.clause = ClauseInsert
+				c.insertColumns = false
+			}
+			if c.starModifier {
+				clause.clause = ""
+				clause.starModifier = true
+				c.starModifier = false
+			}
+			c.clauses = append(c.clauses, clause)
+		case ")":
+			if len(c.clauses) > 1 {
+				// star modifiers can be chained, e.g. * EXCLUDE (a) REPLACE (b AS c)
+				c.star = current.starModifier
+				c.clauses = c.clauses[:len(c.clauses)-1]
+			}
+		case ",":
+			c.setTargetNext = current.clause == ClauseSet
+		case ";":
+			*c = columnState{clauses: c.clauses[:0]}
+			return &columnClause{}, false
+		}
+		return &c.clauses[len(c.clauses)-1], false
+	case COMMAND:
+		switch canonicalCommand(value, state.dbms) {
+		case "SELECT":
+			current.clause = ClauseSelect
+			c.insertColumns = false
+		case "JOIN", "STRAIGHT_JOIN":
+			current.clause = ""
+			current.joined = true
+		case "INSERT", "UPSERT":
+			current.clause = ""
+			c.insertColumns = true
+		default:
+			current.clause = ""
+		}
+		return current, false
+	case KEYWORD, IDENT:
+		if state.dbms == DBMSDuckDB && afterStar && isStarModifier(token.Value) {
+			c.starModifier = true
+			return current, false
+		}
+		if c.starModifier {
+			// the single column of a star modifier without parentheses, e.g. * EXCLUDE a
+			c.starModifier = false
+			c.star = true
+			return current, false
+		}
+		clause, ok := lookupKeyword(columnClauseKeywords, value)
+		if !ok {
+			break
+		}
+		if current.function != "" || strings.EqualFold(value, "GROUP") && lastValueToken != nil && strings.EqualFold(lastValueToken.Value, "WITHIN") {
+			// the arguments of a function or a window keep the clause of the call,
+			// e.g. EXTRACT(YEAR FROM ts), STRING_AGG(a, ',' ORDER BY b) or OVER (ORDER BY b)
+			return current, false
+		}
+		switch {
+		case strings.EqualFold(value, "ON"), strings.EqualFold(value, "USING"):
+			if !current.joined && state.tables.command() != "MERGE" {
+				// e.g. ON CONFLICT in PostgreSQL or DELETE FROM t USING u
+				clause = ""
+			}
+		case strings.EqualFold(value, "SET"):
+			if command := state.tables.command(); command != "UPDATE" && command != "MERGE" {
+				clause = ""
+			}
+			c.setTargetNext = clause != ""
+		case strings.EqualFold(value, "VALUES"):
+			c.insertColumns = false
+		}
+		current.clause = clause
+		return current, false
 	}
-}
 
-func WithRemoveSpaceBetweenParentheses(removeSpaceBetweenParentheses bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.RemoveSpaceBetweenParentheses = removeSpaceBetweenParentheses
+	if token.Type != IDENT && token.Type != QUOTED_IDENT || current.clause == "" {
+		return current, false
+	}
+	if current.clause == ClauseSet {
+		if !c.setTargetNext {
+			// the assigned expression
+			return current, false
+		}
+		c.setTargetNext = false
+		return current, true
 	}
+	return current, isColumnPosition(token, lastValueToken, current, afterTop)
 }
 
-func WithKeepTrailingSemicolon(keepTrailingSemicolon bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.KeepTrailingSemicolon = keepTrailingSemicolon
+// columnRef splits a column into the table or alias qualifying it and its name, e.g. u and email for u.email
+func (n *Normalizer) columnRef(token *Token, clause string, state *metadataState) *ColumnRef {
+	name, quotes := token.Value, token.quotes
+	if n.config.FoldIdentifierCase {
+		name = foldIdentifierCase(name, quotes, state.dbms)
+	}
+	parts, _ := splitQualifiedName(name, quotes)
+	if len(parts) == 0 || strings.HasSuffix(token.Value, ".") {
+		// e.g. t. in t.*
+		return nil
+	}
+	return &ColumnRef{
+		Table:  strings.Join(parts[:len(parts)-1], "."),
+		Name:   parts[len(parts)-1],
+		Clause: clause,
 	}
 }
 
-func WithKeepIdentifierQuotation(keepIdentifierQuotation bool) normalizerOption {
-	return func(c *normalizerConfig) {
-		c.KeepIdentifierQuotation = keepIdentifierQuotation
+// collectPredicate collects the predicates of WHERE, ON and HAVING whose left operand is a column,
+// e.g. o.total > ?, u.id IN (SELECT ...) or deleted_at IS NULL
+func collectPredicate(token *Token, column *ColumnRef, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	p := &state.predicate
+	value := token.Value
+	if value == ";" {
+		*p = predicateState{}
+		return
+	}
+	add := func(operator, right string) {
+		meta.addPredicate(Predicate{Column: p.column, Operator: operator, Right: right, Clause: p.clause}, statementMetadata)
+		*p = predicateState{}
 	}
-}
 
-type StatementMetadata struct {
-	Size       int      `json:"size"`
-	Tables     []string `json:"tables"`
-	Comments   []string `json:"comments"`
-	Commands   []string `json:"commands"`
-	Procedures []string `json:"procedures"`
-}
+	switch p.stage {
+	case predicateColumn:
+		switch {
+		case value == "::" && p.operator == "":
+			p.cast = true
+			return
+		case p.cast && token.Type == IDENT:
+			// the type of the cast
+			p.cast = false
+			return
+		case token.Type == OPERATOR && isComparisonOperator(value) && p.operator == "":
+			p.operator = value
+			p.stage = predicateOperator
+			return
+		case isPredicateKeyword(value) && p.operator != "IS" && p.operator != "IS NOT":
+			p.operator = strings.TrimSpace(p.operator + " " + strings.ToUpper(value))
+			p.stage = predicateOperator
+			return
+		case strings.EqualFold(value, "NOT") || strings.EqualFold(value, "IS") && p.operator == "":
+			// NOT IN, NOT LIKE, NOT BETWEEN or IS NOT NULL
+			p.operator = strings.TrimSpace(p.operator + " " + strings.ToUpper(value))
+			return
+		case token.Type == NULL && strings.HasPrefix(p.operator, "IS"):
+			add(p.operator+" NULL", "")
+			return
+		}
+		*p = predicateState{}
+	case predicateOperator:
+		switch {
+		case value == "(":
+			p.stage = predicateList
+			return
+		case strings.EqualFold(value, "ANY") || strings.EqualFold(value, "ALL") || strings.EqualFold(value, "SOME"):
+			// a list or a subquery follows, e.g. = ANY (SELECT ...)
+			return
+		case token.Type == OPERATOR && (value == "-" || value == "+"):
+			// a signed number
+			return
+		case token.Type == OPERATOR && (value == ":" || value == "%"):
+			// a named parameter, e.g. :name or %(name)s
+			p.prefix = true
+			return
+		case token.Type == IDENT && !p.prefix && isLiteralPrefix(value):
+			// a typed literal, e.g. DATE '2024-01-01', or a column, e.g. a = date
+			p.literalPrefix = operandKind(token, false)
+			return
+		}
+		add(p.operator, operandKind(token, p.prefix))
+		return
+	case predicateList:
+		switch {
+		case token.Type == COMMAND || token.Type == CTE_INDICATOR:
+			add(p.operator, OperandSubquery)
+		case token.Type == IDENT && isLiteralPrefix(value):
+			// a typed literal, e.g. N'US' in SQL Server, or a column, e.g. IN (date, ...)
+			p.literalPrefix = operandKind(token, false)
+		default:
+			add(p.operator, operandKind(token, false))
+		}
+		return
+	}
 
-type metadataSet struct {
-	size          int
-	tablesSet     map[string]struct{}
-	commentsSet   map[string]struct{}
-	commandsSet   map[string]struct{}
-	proceduresSet map[string]struct{}
+	if column != nil && (column.Clause == ClauseWhere || column.Clause == ClauseJoin || column.Clause == ClauseHaving) {
+		p.stage = predicateColumn
+		p.column = column.Name
+		if column.Table != "" {
+			p.column = column.Table + "." + column.Name
+		}
+		p.clause = column.Clause
+	}
 }
 
-// addMetadata adds a value to a metadata slice if it doesn't exist in the set
-func (m *metadataSet) addMetadata(value string, set map[string]struct{}, slice *[]string) {
-	if _, exists := set[value]; !exists {
-		set[value] = struct{}{}
-		*slice = append(*slice, value)
-		m.size += len(value)
+// collectLiteralPrefixOperand adds the predicate whose right operand may prefix a typed literal once the next token
+// tells a typed literal, e.g. DATE '2024-01-01' or N'US', from a column, e.g. date in a = date.
+// It reports whether the token is the string of a typed literal.
+func collectLiteralPrefixOperand(token *Token, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) bool {
+	p := &state.predicate
+	right := p.literalPrefix
+	isLiteral := token.Type == STRING || token.Type == INCOMPLETE_STRING
+	if isLiteral {
+		right = OperandLiteral
 	}
+	meta.addPredicate(Predicate{Column: p.column, Operator: p.operator, Right: right, Clause: p.clause}, statementMetadata)
+	*p = predicateState{}
+	return isLiteral
 }
 
-type groupablePlaceholder struct {
-	groupable bool
+// operandKind returns the kind of the right operand of a predicate starting with the token
+func operandKind(token *Token, afterPrefix bool) string {
+	if afterPrefix {
+		// e.g. :name or :1
+		return OperandParameter
+	}
+	switch token.Type {
+	case STRING, INCOMPLETE_STRING, NUMBER, DOLLAR_QUOTED_STRING, BOOLEAN, NULL, UUID, COLLECTION_LITERAL:
+		return OperandLiteral
+	case POSITIONAL_PARAMETER, BIND_PARAMETER, SUBSTITUTION_VARIABLE, SYSTEM_VARIABLE:
+		return OperandParameter
+	case OPERATOR:
+		if token.Value == "?" {
+			return OperandParameter
+		}
+	case IDENT, QUOTED_IDENT:
+		if _, ok := lookupKeyword(columnStopWords, token.Value); !ok || token.Type == QUOTED_IDENT {
+			return OperandColumn
+		}
+	}
+	return OperandExpression
 }
 
-type headState struct {
-	readFirstNonSpaceNonComment         bool
-	inLeadingParenthesesExpression      bool
-	foundLeadingExpressionInParentheses bool
-	standaloneExpressionInParentheses   bool
-	expressionInParentheses             strings.Builder
+// isColumnPosition checks if an identifier in a clause referencing columns is a column rather than
+// an alias, a type or a keyword the lexer reads as an identifier, e.g. NULLS in ORDER BY a NULLS LAST
+func isColumnPosition(token *Token, lastValueToken *LastValueToken, clause *columnClause, afterTop bool) bool {
+	if _, ok := lookupKeyword(columnStopWords, token.Value); ok && token.Type == IDENT {
+		return false
+	}
+	if lastValueToken == nil || afterTop {
+		return true
+	}
+	if lastValueToken.isTableIndicator && clause.function == "" || lastValueToken.Type == ALIAS_INDICATOR || lastValueToken.Value == "::" {
+		// a table, an alias or a type, e.g. x::int
+		return false
+	}
+	if strings.EqualFold(clause.function, "EXTRACT") && lastValueToken.Value == "(" {
+		// the date part of EXTRACT(YEAR FROM ts)
+		return false
+	}
+	switch lastValueToken.Type {
+	case IDENT:
+		if _, ok := lookupKeyword(columnStopWords, lastValueToken.Value); ok {
+			// e.g. a in CASE WHEN a
+			break
+		}
+		// an alias without AS, e.g. SELECT a b
+		return false
+	case QUOTED_IDENT, NUMBER, STRING:
+		return false
+	}
+	return lastValueToken.Value != ")" && !strings.EqualFold(lastValueToken.Value, "END")
 }
 
-type Normalizer struct {
-	config *normalizerConfig
+// isWindowKeyword checks if the parenthesis following the keyword holds a window or an ordered-set specification,
+// e.g. OVER (ORDER BY a) or WITHIN GROUP (ORDER BY a)
+func isWindowKeyword(value string) bool {
+	return strings.EqualFold(value, "OVER") || strings.EqualFold(value, "GROUP")
 }
 
-func NewNormalizer(opts ...normalizerOption) *Normalizer {
-	normalizer := Normalizer{
-		config: &normalizerConfig{},
+// isColumnQualified checks if the token following a column tells that the column is a qualifier
+// or the prefix of a literal rather than a column, e.g. "t" in "t".*, DATE in DATE '2024-01-01' or N in N'text'
+func isColumnQualified(token *Token, column *ColumnRef) bool {
+	if token.Value == "." {
+		return true
 	}
-
-	for _, opt := range opts {
-		opt(normalizer.config)
+	switch token.Type {
+	case STRING, INCOMPLETE_STRING:
+		_, ok := lookupKeyword(literalPrefixes, column.Name)
+		return ok && column.Table == ""
 	}
-
-	return &normalizer
+	return false
 }
 
-// normalizeToken is a helper function that handles the common normalization logic
-func (n *Normalizer) normalizeToken(lexer *Lexer, normalizedSQLBuilder *strings.Builder, meta *metadataSet, statementMetadata *StatementMetadata, preProcessToken func(*Token, *LastValueToken), lexerOpts ...lexerOption) error {
-	var groupablePlaceholder groupablePlaceholder
-	var headState headState
-	var ctes map[string]bool
+// collectFunction collects a function call, skipping the tokens the lexer reads as functions which are not calls,
+// e.g. the table of INSERT INTO t(a), the type of CAST(a AS VARCHAR(20)), the function defined by CREATE FUNCTION f(a INT)
+// or the procedure called by BEGIN pkg.proc(1); END; in Oracle, which is collected as a procedure
+func collectFunction(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	if _, ok := lookupKeyword(functionLikeTypes, token.Value); ok {
+		return
+	}
+	if state.plsql.procedureCall {
+		// a procedure called as a statement of a PL/SQL block, e.g. BEGIN pkg.proc(1); END;
+		return
+	}
+	if lastValueToken != nil {
+		if lastValueToken.Type == ALIAS_INDICATOR {
+			// the column list of an alias, e.g. u(x, i) in UNNEST(a) WITH ORDINALITY AS u(x, i)
+			return
+		}
+		if _, ok := lookupKeyword(functionNameKeywords, lastValueToken.Value); ok {
+			// definitions and procedure calls
+			return
+		}
+		_, isTableFunction := lookupKeyword(tableFunctionKeywords, lastValueToken.Value)
+		if lastValueToken.isTableIndicator && !isTableFunction && lastValueToken.Value != "," {
+			// a table followed by its columns, e.g. INSERT INTO t(a) or CREATE TABLE t(id INT),
+			// but not a table function, e.g. FROM generate_series(1, 10)
+			return
+		}
+	}
 
-	// Only allocate CTEs map if collecting tables
-	if n.config.CollectTables {
-		ctes = make(map[string]bool, 2)
+	builtIn := isBuiltinFunction(token.Value, state.dbms)
+	name := foldIdentifierCase(token.Value, token.quotes, state.dbms)
+	if builtIn && identifierCaseFolding(state.dbms) == nil {
+		// built-in functions are case insensitive even in the dialects not folding identifiers
+		name = strings.ToUpper(token.Value)
 	}
+	meta.addFunction(FunctionCall{
+		Name:             name,
+		BuiltIn:          builtIn,
+		NonDeterministic: builtIn && isNonDeterministicFunction(token.Value),
+		Count:            1,
+	}, statementMetadata)
+}
 
-	var lastValueToken *LastValueToken
+// collectStructure measures the subquery depth, the set operations and the joins of a statement
+func collectStructure(token *Token, lastValueToken *LastValueToken, statementMetadata *StatementMetadata, state *metadataState) {
+	s := &state.structure
+	value := token.Value
+	if token.Type == EOF || value == ";" {
+		if s.setOps > 0 {
+			statementMetadata.SetOperationBranches += s.setOps + 1
+		}
+		*s = structureState{parens: s.parens[:0]}
+		return
+	}
+	if !isValueToken(token) {
+		return
+	}
 
-	for {
-		token := lexer.Scan()
-		if preProcessToken != nil {
-			// pre-process the token, often used for obfuscation
-			preProcessToken(token, lastValueToken)
+	switch token.Type {
+	case CTE_INDICATOR:
+		s.cteList = true
+		s.cteLevel = len(s.parens)
+	case PUNCTUATION:
+		switch value {
+		case "(":
+			if lastValueToken != nil && lastValueToken.Type == CTE_INDICATOR {
+				// not a CTE, e.g. WITH (NOLOCK) in SQL Server
+				s.cteList = false
+			}
+			cteBody := s.cteList && lastValueToken != nil && lastValueToken.Type == ALIAS_INDICATOR
+			s.parens = append(s.parens, structureParen{cteBody: cteBody})
+		case ")":
+			if len(s.parens) == 0 {
+				return
+			}
+			paren := s.parens[len(s.parens)-1]
+			s.parens = s.parens[:len(s.parens)-1]
+			if paren.setOps > 0 {
+				statementMetadata.SetOperationBranches += paren.setOps + 1
+			}
+			if paren.subquery {
+				s.depth--
+			}
 		}
-		if n.shouldCollectMetadata() {
-			n.collectMetadata(token, lastValueToken, meta, statementMetadata, ctes)
+	case COMMAND:
+		if len(s.parens) == s.cteLevel {
+			// the statement following the CTEs
+			s.cteList = false
 		}
-		n.normalizeSQL(token, lastValueToken, normalizedSQLBuilder, &groupablePlaceholder, &headState, lexerOpts...)
-		if token.Type == EOF {
-			break
+		switch canonicalCommand(value, state.dbms) {
+		case "SELECT":
+			if len(s.parens) > 0 && lastValueToken != nil && lastValueToken.Value == "(" {
+				paren := &s.parens[len(s.parens)-1]
+				if !paren.cteBody && !paren.subquery {
+					paren.subquery = true
+					s.depth++
+					statementMetadata.SubqueryDepth = max(statementMetadata.SubqueryDepth, s.depth)
+				}
+			}
+		case "JOIN", "STRAIGHT_JOIN":
+			statementMetadata.Joins++
 		}
-		if isValueToken(token) {
-			lastValueToken = token.getLastValueToken()
+	case KEYWORD, IDENT:
+		switch {
+		case strings.EqualFold(value, "RECURSIVE"):
+			if lastValueToken != nil && lastValueToken.Type == CTE_INDICATOR {
+				statementMetadata.RecursiveCTE = true
+			}
+		case isSetOperator(value):
+			if len(s.parens) > 0 {
+				s.parens[len(s.parens)-1].setOps++
+			} else {
+				s.setOps++
+			}
+		case strings.EqualFold(value, "APPLY"):
+			if state.dbms == DBMSSQLServer {
+				// CROSS APPLY or OUTER APPLY
+				statementMetadata.Joins++
+			}
 		}
 	}
-
-	return nil
 }
 
-func (n *Normalizer) Normalize(input string, lexerOpts ...lexerOption) (normalizedSQL string, statementMetadata *StatementMetadata, err error) {
-	lexer := New(input, lexerOpts...)
-	var normalizedSQLBuilder strings.Builder
-	normalizedSQLBuilder.Grow(len(input))
-
-	meta := &metadataSet{
-		tablesSet:     map[string]struct{}{},
-		commentsSet:   map[string]struct{}{},
-		commandsSet:   map[string]struct{}{},
-		proceduresSet: map[string]struct{}{},
+// collectSchemas collects the databases and schemas selected by USE db, USE SCHEMA db.s in Snowflake,
+// SET search_path TO a, b in PostgreSQL, ALTER SESSION SET CURRENT_SCHEMA = s in Oracle or a \connect db directive
+func (n *Normalizer) collectSchemas(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) {
+	s := &state.schema
+	if token.Type == CLIENT_DIRECTIVE {
+		if db, ok := connectDatabase(token.Value); ok {
+			meta.addMetadata(db, &meta.databasesSet, &statementMetadata.Databases)
+		}
+		return
 	}
-
-	statementMetadata = &StatementMetadata{
-		Tables:     []string{},
-		Comments:   []string{},
-		Commands:   []string{},
-		Procedures: []string{},
+	if !isValueToken(token) {
+		return
 	}
+	word := token.Value
+	isName := token.Type == IDENT || token.Type == QUOTED_IDENT
 
-	if err = n.normalizeToken(lexer, &normalizedSQLBuilder, meta, statementMetadata, nil, lexerOpts...); err != nil {
-		return "", nil, err
+	switch s.stage {
+	case schemaUse:
+		if !s.schema && lastValueToken != nil && lastValueToken.Type == COMMAND && token.Type != QUOTED_IDENT {
+			// the object selected, e.g. USE DATABASE db or USE WAREHOUSE wh in Snowflake
+			switch {
+			case strings.EqualFold(word, "DATABASE"), strings.EqualFold(word, "CATALOG"):
+				return
+			case strings.EqualFold(word, "SCHEMA"):
+				s.schema = true
+				return
+			}
+			if _, ok := lookupKeyword(useObjectKinds, word); ok {
+				*s = schemaState{}
+				return
+			}
+		}
+		if isName {
+			parts := n.schemaNameParts(token, state.dbms)
+			if s.schema && len(parts) > 0 {
+				// USE SCHEMA db.s
+				meta.addMetadata(parts[len(parts)-1], &meta.schemasSet, &statementMetadata.Schemas)
+				parts = parts[:len(parts)-1]
+			}
+			if len(parts) > 0 {
+				meta.addMetadata(strings.Join(parts, "."), &meta.databasesSet, &statementMetadata.Databases)
+			}
+		}
+		*s = schemaState{}
+		return
+	case schemaSet:
+		switch {
+		case strings.EqualFold(word, "SESSION"), strings.EqualFold(word, "LOCAL"):
+			return
+		case strings.EqualFold(word, "SEARCH_PATH"), strings.EqualFold(word, "CURRENT_SCHEMA"):
+			s.stage = schemaAssign
+			return
+		case strings.EqualFold(word, "SCHEMA"):
+			// SET SCHEMA 'app' in PostgreSQL
+			s.stage = schemaList
+			return
+		}
+		*s = schemaState{}
+	case schemaAssign:
+		if strings.EqualFold(word, "TO") || word == "=" {
+			s.stage = schemaList
+			return
+		}
+		*s = schemaState{}
+	case schemaList:
+		switch {
+		case word == ",":
+			return
+		case isName:
+			meta.addMetadata(strings.Join(n.schemaNameParts(token, state.dbms), "."), &meta.schemasSet, &statementMetadata.Schemas)
+			return
+		case token.Type == STRING && token.Value != StringPlaceholder:
+			// SET search_path = 'a, b'
+			for _, schema := range strings.Split(strings.Trim(token.Value, "'"), ",") {
+				if schema = strings.TrimSpace(schema); schema != "" {
+					meta.addMetadata(schema, &meta.schemasSet, &statementMetadata.Schemas)
+				}
+			}
+			return
+		}
+		*s = schemaState{}
 	}
 
-	normalizedSQL = normalizedSQLBuilder.String()
-	statementMetadata.Size = meta.size
-	return n.trimNormalizedSQL(normalizedSQL), statementMetadata, nil
+	switch {
+	case token.Type == COMMAND && strings.EqualFold(word, "USE"):
+		s.stage = schemaUse
+	case strings.EqualFold(word, "SET") && (lastValueToken == nil || lastValueToken.Value == ";" || strings.EqualFold(lastValueToken.Value, "SESSION")):
+		// a statement setting a variable rather than the SET clause of UPDATE
+		s.stage = schemaSet
+	}
 }
 
-func (n *Normalizer) shouldCollectMetadata() bool {
-	return n.config.CollectTables || n.config.CollectCommands || n.config.CollectComments || n.config.CollectProcedure
+// schemaNameParts returns the parts of a database or schema name without their quotes, e.g. sales and dbo for [sales].dbo
+func (n *Normalizer) schemaNameParts(token *Token, dbms DBMSType) []string {
+	value := token.Value
+	if n.config.FoldIdentifierCase {
+		value = foldIdentifierCase(value, token.quotes, dbms)
+	}
+	parts, _ := splitQualifiedName(value, token.quotes)
+	return parts
 }
 
-func (n *Normalizer) collectMetadata(token *Token, lastValueToken *LastValueToken, meta *metadataSet, statementMetadata *StatementMetadata, ctes map[string]bool) {
-	if n.config.CollectComments && (token.Type == COMMENT || token.Type == MULTILINE_COMMENT) {
-		comment := token.Value
-		meta.addMetadata(comment, meta.commentsSet, &statementMetadata.Comments)
-	} else if token.Type == COMMAND {
-		if n.config.CollectCommands {
-			command := strings.ToUpper(token.Value)
-			meta.addMetadata(command, meta.commandsSet, &statementMetadata.Commands)
+// collectSequence collects sequences, e.g. NEXT VALUE FOR seq, NEXTVAL(seq), seq.NEXTVAL or CREATE SEQUENCE seq.
+// It returns true if the token names a sequence, so that it is not collected as a table.
+func (n *Normalizer) collectSequence(token *Token, meta *metadataSet, statementMetadata *StatementMetadata, state *metadataState) bool {
+	if !isValueToken(token) {
+		return false
+	}
+	switch state.sequence {
+	case sequenceNameNext:
+		if strings.EqualFold(token.Value, "IF") || strings.EqualFold(token.Value, "NOT") || strings.EqualFold(token.Value, "EXISTS") {
+			// CREATE SEQUENCE IF NOT EXISTS seq
+			return false
 		}
-	} else if token.Type == IDENT || token.Type == QUOTED_IDENT || token.Type == FUNCTION {
-		tokenVal := token.Value
-		if token.Type == QUOTED_IDENT {
-			tokenVal = trimQuotes(token)
-			if !n.config.KeepIdentifierQuotation {
-				// trim quotes and set the token type to IDENT
-				token.Value = tokenVal
-				token.Type = IDENT
-			}
+		state.sequence = sequenceNone
+		if token.Type == IDENT || token.Type == QUOTED_IDENT {
+			meta.addMetadata(n.metadataName(token, state.dbms), &meta.sequencesSet, &statementMetadata.Sequences)
+			return true
 		}
-		if lastValueToken != nil && lastValueToken.Type == CTE_INDICATOR {
-			ctes[tokenVal] = true
-		} else if n.config.CollectTables && lastValueToken != nil && lastValueToken.isTableIndicator {
-			if _, ok := ctes[tokenVal]; !ok {
-				meta.addMetadata(tokenVal, meta.tablesSet, &statementMetadata.Tables)
-			}
-		} else if n.config.CollectProcedure && lastValueToken != nil && lastValueToken.Type == PROC_INDICATOR {
-			meta.addMetadata(tokenVal, meta.proceduresSet, &statementMetadata.Procedures)
+		return false
+	case sequenceAfterNext:
+		if strings.EqualFold(token.Value, "VALUE") {
+			state.sequence = sequenceAfterValue
+			return false
+		}
+	case sequenceAfterValue:
+		if strings.EqualFold(token.Value, "FOR") {
+			state.sequence = sequenceNameNext
+			return false
+		}
+	case sequenceAfterFunction:
+		if token.Value == "(" {
+			state.sequence = sequenceNameNext
+			return false
+		}
+	case sequenceAfterDDL:
+		if strings.EqualFold(token.Value, "SEQUENCE") {
+			state.sequence = sequenceNameNext
+			return false
 		}
 	}
+
+	state.sequence = sequenceStart(token)
+	if state.sequence == sequenceNone && (token.Type == IDENT || token.Type == QUOTED_IDENT) && isSequencePseudoColumn(token.Value) {
+		// seq.NEXTVAL or seq.CURRVAL in Oracle and Snowflake
+		name := n.metadataName(token, state.dbms)
+		meta.addMetadata(name[:strings.LastIndexByte(name, '.')], &meta.sequencesSet, &statementMetadata.Sequences)
+		return true
+	}
+	return false
 }
 
 func (n *Normalizer) normalizeSQL(token *Token, lastValueToken *LastValueToken, normalizedSQLBuilder *strings.Builder, groupablePlaceholder *groupablePlaceholder, headState *headState, lexerOpts ...lexerOption) {
-	if token.Type != SPACE && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
+	if token.Type != SPACE && token.Type != COMMENT && token.Type != MULTILINE_COMMENT && token.Type != FEATURE_COMMENT && token.Type != CLIENT_DIRECTIVE {
 		if token.Type == QUOTED_IDENT && !n.config.KeepIdentifierQuotation {
 			token.Value = trimQuotes(token)
 		}
@@ -335,27 +2030,31 @@ func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastValueToken *La
 			// if the last token is nil, we know it's the start of groupable placeholders
 			return false
 		}
-		if lastValueToken.Value == "(" || lastValueToken.Value == "[" {
+		// Cassandra also groups the placeholders of collection literals, e.g. {?, ?} or {?: ?}
+		inBraces := groupablePlaceholder.dbms == DBMSCassandra && lastValueToken.Value == "{"
+		if lastValueToken.Value == "(" || lastValueToken.Value == "[" || inBraces {
 			// if the last token is "(" or "[", and the current token is a placeholder,
 			// we know it's the start of groupable placeholders
 			// we don't return here because we still need to write the first placeholder
 			groupablePlaceholder.groupable = true
-		} else if lastValueToken.Value == "," && groupablePlaceholder.groupable {
+			groupablePlaceholder.inBraces = inBraces
+		} else if groupablePlaceholder.groupable && groupablePlaceholder.isSeparator(lastValueToken.Value) {
 			return true
 		}
 	}
 
-	if lastValueToken != nil && (lastValueToken.Value == NumberPlaceholder || lastValueToken.Value == StringPlaceholder) && token.Value == "," && groupablePlaceholder.groupable {
+	if lastValueToken != nil && (lastValueToken.Value == NumberPlaceholder || lastValueToken.Value == StringPlaceholder) && groupablePlaceholder.groupable && groupablePlaceholder.isSeparator(token.Value) {
 		return true
 	}
 
-	if groupablePlaceholder.groupable && (token.Value == ")" || token.Value == "]") {
+	if groupablePlaceholder.groupable && (token.Value == ")" || token.Value == "]" || (groupablePlaceholder.inBraces && token.Value == "}")) {
 		// end of groupable placeholders
 		groupablePlaceholder.groupable = false
+		groupablePlaceholder.inBraces = false
 		return false
 	}
 
-	if groupablePlaceholder.groupable && token.Value != NumberPlaceholder && token.Value != StringPlaceholder && lastValueToken != nil && lastValueToken.Value == "," {
+	if groupablePlaceholder.groupable && token.Value != NumberPlaceholder && token.Value != StringPlaceholder && lastValueToken != nil && groupablePlaceholder.isSeparator(lastValueToken.Value) {
 		// This is a tricky edge case. If we are inside a groupbale block, and the current token is not a placeholder,
 		// we not only want to write the current token to the normalizedSQLBuilder, but also write the last comma that we skipped.
 		// For example, (?, ARRAY[?, ?, ?]) should be normalized as (?, ARRAY[?])
@@ -366,6 +2065,12 @@ func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastValueToken *La
 	return false
 }
 
+// isSeparator checks if a token separates groupable placeholders,
+// e.g. the comma in (?, ?) or the colon in a map literal {?: ?}
+func (g *groupablePlaceholder) isSeparator(value string) bool {
+	return value == "," || (g.inBraces && value == ":")
+}
+
 func (n *Normalizer) appendSpace(token *Token, lastValueToken *LastValueToken, normalizedSQLBuilder *strings.Builder) {
 	// do not add a space between parentheses if RemoveSpaceBetweenParentheses is true
 	if n.config.RemoveSpaceBetweenParentheses && lastValueToken != nil && (lastValueToken.Type == FUNCTION || lastValueToken.Value == "(" || lastValueToken.Value == "[") {
@@ -376,6 +2081,12 @@ func (n *Normalizer) appendSpace(token *Token, lastValueToken *LastValueToken, n
 		return
 	}
 
+	if token.Type == INDEX_HINT || token.Type == VARIANT_PATH || token.Type == DB_LINK {
+		// index hints, semi-structured paths and database links are attached to the preceding token,
+		// e.g. users@users_email_idx, v:customer.name or orders@remote_db
+		return
+	}
+
 	switch token.Value {
 	case ",", ";":
 		return
@@ -283,11 +283,11 @@ multiline comment */
 			input:    "/* Testing explicit table SQL expression */ WITH T1 AS (SELECT PNO , PNAME , COLOR , WEIGHT , CITY FROM P WHERE CITY = ?), T2 AS (SELECT PNO, PNAME, COLOR, WEIGHT, CITY, ? * WEIGHT AS NEW_WEIGHT, ? AS NEW_CITY FROM T1), T3 AS ( SELECT PNO , PNAME, COLOR, NEW_WEIGHT AS WEIGHT, NEW_CITY AS CITY FROM T2), T4 AS ( TABLE P EXCEPT CORRESPONDING TABLE T1) TABLE T4 UNION CORRESPONDING TABLE T3",
 			expected: "WITH T1 AS ( SELECT PNO, PNAME, COLOR, WEIGHT, CITY FROM P WHERE CITY = ? ), T2 AS ( SELECT PNO, PNAME, COLOR, WEIGHT, CITY, ? * WEIGHT, ? FROM T1 ), T3 AS ( SELECT PNO, PNAME, COLOR, NEW_WEIGHT, NEW_CITY FROM T2 ), T4 AS ( TABLE P EXCEPT CORRESPONDING TABLE T1 ) TABLE T4 UNION CORRESPONDING TABLE T3",
 			statementMetadata: StatementMetadata{
-				Tables:     []string{"P", "T2", "T4", "T3"},
+				Tables:     []string{"P"},
 				Comments:   []string{"/* Testing explicit table SQL expression */"},
 				Commands:   []string{"SELECT"},
 				Procedures: []string{},
-				Size:       56,
+				Size:       50,
 			},
 		},
 		{
@@ -306,11 +306,11 @@ multiline comment */
 			input:    "SELECT d.id, d.uuid, d.org_id, d.creator_id, d.updater_id, d.monitor_id, d.parent_id, d.original_parent_id, d.scope, d.start_dt, d.end_dt, d.canceled_dt, d.active, d.disabled, d.created, d.modified, d.message, d.monitor_tags, d.recurrence, d.mute_first_recovery_notification, d.scope_v2_query, d.scope_v2 FROM monitor_downtime d, org o WHERE o.id = d.org_id AND d.modified >= ? AND o.partition_num = ANY (?, ?, ?)",
 			expected: "SELECT d.id, d.uuid, d.org_id, d.creator_id, d.updater_id, d.monitor_id, d.parent_id, d.original_parent_id, d.scope, d.start_dt, d.end_dt, d.canceled_dt, d.active, d.disabled, d.created, d.modified, d.message, d.monitor_tags, d.recurrence, d.mute_first_recovery_notification, d.scope_v2_query, d.scope_v2 FROM monitor_downtime d, org o WHERE o.id = d.org_id AND d.modified >= ? AND o.partition_num = ANY ( ? )",
 			statementMetadata: StatementMetadata{
-				Tables:     []string{"monitor_downtime"},
+				Tables:     []string{"monitor_downtime", "org"},
 				Comments:   []string{},
 				Commands:   []string{"SELECT"},
 				Procedures: []string{},
-				Size:       22,
+				Size:       25,
 			},
 		},
 		{
@@ -947,8 +947,9 @@ func TestNormalizeDeobfuscatedSQL(t *testing.T) {
 
 func TestGroupObfuscatedValues(t *testing.T) {
 	tests := []struct {
-		input    string
-		expected string
+		input     string
+		expected  string
+		lexerOpts []lexerOption
 	}{
 		{
 			input:    "(?)",
@@ -1046,12 +1047,21 @@ func TestGroupObfuscatedValues(t *testing.T) {
 			input:    "[ ? ]",
 			expected: "[ ? ]",
 		},
+		{
+			input:     "{?, ?, ?}",
+			expected:  "{ ? }",
+			lexerOpts: []lexerOption{WithDBMS(DBMSCassandra)},
+		},
+		{
+			input:    "{?, ?, ?}",
+			expected: "{ ?, ?, ? }",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
 			normalizer := NewNormalizer()
-			got, _, _ := normalizer.Normalize(test.input)
+			got, _, _ := normalizer.Normalize(test.input, test.lexerOpts...)
 			assert.Equal(t, test.expected, got)
 		})
 	}
@@ -1197,6 +1207,76 @@ func TestNormalizerKeepTrailingSemicolon(t *testing.T) {
 	}
 }
 
+func TestNormalizerSchemasScriptMode(t *testing.T) {
+	normalizer := NewNormalizer(WithCollectTables(true), WithCollectSchemas(true))
+	_, statementMetadata, err := normalizer.Normalize("\\connect inventory\nSELECT * FROM items", WithDBMS(DBMSPostgres), WithScriptMode(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inventory"}, statementMetadata.Databases)
+	assert.Nil(t, statementMetadata.Schemas)
+}
+
+func TestNormalizeMulti(t *testing.T) {
+	input := "INSERT INTO orders (id) SELECT id FROM carts; /* cleanup */ DELETE FROM carts WHERE id IN (?, ?);"
+	normalizer := NewNormalizer(
+		WithCollectComments(true),
+		WithCollectCommands(true),
+		WithCollectTables(true),
+		WithMergeStatements(true),
+	)
+
+	result, err := normalizer.NormalizeMulti(input, WithDBMS(DBMSMySQL))
+	assert.NoError(t, err)
+	assert.Equal(t, []NormalizedStatement{
+		{
+			Statement:     Statement{Text: "INSERT INTO orders (id) SELECT id FROM carts", Start: 0, End: 44},
+			NormalizedSQL: "INSERT INTO orders ( id ) SELECT id FROM carts",
+			StatementMetadata: &StatementMetadata{
+				Size:       23,
+				Tables:     []string{"orders", "carts"},
+				Comments:   []string{},
+				Commands:   []string{"INSERT", "SELECT"},
+				Procedures: []string{},
+			},
+		},
+		{
+			Statement:     Statement{Text: "/* cleanup */ DELETE FROM carts WHERE id IN (?, ?)", Start: 46, End: 96},
+			NormalizedSQL: "DELETE FROM carts WHERE id IN ( ? )",
+			StatementMetadata: &StatementMetadata{
+				Size:       24,
+				Tables:     []string{"carts"},
+				Comments:   []string{"/* cleanup */"},
+				Commands:   []string{"DELETE"},
+				Procedures: []string{},
+			},
+		},
+	}, result.Statements)
+
+	assert.Equal(t, &NormalizedStatement{
+		Statement:     Statement{Text: input, Start: 0, End: len(input)},
+		NormalizedSQL: "INSERT INTO orders ( id ) SELECT id FROM carts; DELETE FROM carts WHERE id IN ( ? )",
+		StatementMetadata: &StatementMetadata{
+			Size:       42,
+			Tables:     []string{"orders", "carts"},
+			Comments:   []string{"/* cleanup */"},
+			Commands:   []string{"INSERT", "SELECT", "DELETE"},
+			Procedures: []string{},
+		},
+	}, result.Merged)
+}
+
+func TestNormalizeMultiWithoutMerge(t *testing.T) {
+	normalizer := NewNormalizer(WithCollectTables(true), WithKeepTrailingSemicolon(true))
+
+	result, err := normalizer.NormalizeMulti("BEGIN UPDATE t SET a = ?; END;\nSELECT * FROM u", WithDBMS(DBMSOracle))
+	assert.NoError(t, err)
+	assert.Nil(t, result.Merged)
+	assert.Len(t, result.Statements, 2)
+	assert.Equal(t, "BEGIN UPDATE t SET a = ?; END;", result.Statements[0].NormalizedSQL)
+	assert.Equal(t, []string{"t"}, result.Statements[0].StatementMetadata.Tables)
+	assert.Equal(t, "SELECT * FROM u;", result.Statements[1].NormalizedSQL)
+	assert.Equal(t, []string{"u"}, result.Statements[1].StatementMetadata.Tables)
+}
+
 func ExampleNormalizer() {
 	normalizer := NewNormalizer(
 		WithCollectComments(true),
@@ -1214,15 +1294,11 @@ func ExampleNormalizer() {
 	)
 
 	fmt.Println(normalizedSQL)
-	fmt.Println(statementMetadata)
+	fmt.Println(statementMetadata.Size, statementMetadata.Tables, statementMetadata.Comments, statementMetadata.Commands, statementMetadata.Procedures)
 	// Output: SELECT * FROM users WHERE id in ( ? )
-	// &{34 [users] [/* this is a comment */] [SELECT] []}
+	// 34 [users] [/* this is a comment */] [SELECT] []
 }
 
 func assertStatementMetadataEqual(t *testing.T, expected, actual *StatementMetadata) {
-	assert.Equal(t, expected.Size, actual.Size)
-	assert.Equal(t, expected.Tables, actual.Tables)
-	assert.Equal(t, expected.Comments, actual.Comments)
-	assert.Equal(t, expected.Commands, actual.Commands)
-	assert.Equal(t, expected.Procedures, actual.Procedures)
+	assert.Equal(t, expected, actual)
 }
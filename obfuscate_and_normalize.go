@@ -6,22 +6,12 @@ import "strings"
 // This function is a convenience function that combines the Obfuscator and Normalizer in one pass
 func ObfuscateAndNormalize(input string, obfuscator *Obfuscator, normalizer *Normalizer, lexerOpts ...lexerOption) (normalizedSQL string, statementMetadata *StatementMetadata, err error) {
 	lexer := New(input, lexerOpts...)
+	lexer.embeddedSQL.enabled = obfuscator.config.EmbeddedSQL || normalizer.config.NormalizeEmbeddedSQL
 	var normalizedSQLBuilder strings.Builder
 	normalizedSQLBuilder.Grow(len(input))
 
-	meta := &metadataSet{
-		tablesSet:     map[string]struct{}{},
-		commentsSet:   map[string]struct{}{},
-		commandsSet:   map[string]struct{}{},
-		proceduresSet: map[string]struct{}{},
-	}
-
-	statementMetadata = &StatementMetadata{
-		Tables:     []string{},
-		Comments:   []string{},
-		Commands:   []string{},
-		Procedures: []string{},
-	}
+	meta := newMetadataSet()
+	statementMetadata = newStatementMetadata()
 
 	obfuscate := func(token *Token, lastValueToken *LastValueToken) {
 		obfuscator.ObfuscateTokenValue(token, lastValueToken, lexerOpts...)
@@ -36,3 +26,11 @@ func ObfuscateAndNormalize(input string, obfuscator *Obfuscator, normalizer *Nor
 	statementMetadata.Size = meta.size
 	return normalizer.trimNormalizedSQL(normalizedSQL), statementMetadata, nil
 }
+
+// ObfuscateAndNormalizeMulti splits the input into its statements with SplitStatements
+// and obfuscates and normalizes each statement separately
+func ObfuscateAndNormalizeMulti(input string, obfuscator *Obfuscator, normalizer *Normalizer, lexerOpts ...lexerOption) (*MultiStatementResult, error) {
+	return normalizer.normalizeMulti(input, func(statement string) (string, *StatementMetadata, error) {
+		return ObfuscateAndNormalize(statement, obfuscator, normalizer, lexerOpts...)
+	}, lexerOpts...)
+}
@@ -415,11 +415,12 @@ multiline comment */
 			;`,
 			expected: `COPY INTO REPORTING.GENERAL.MY_TABLE ( FEATURE, DESCRIPTION, COVERAGE, DATE_PARTITION ) FROM ( SELECT $1, $2, $3, TO_TIMESTAMP ( ? ) FROM @REPORTING.GENERAL.SOME_DESCRIPTIONS/external_data/ ) file_format = ( type = CSV SKIP_HEADER = ? FIELD_OPTIONALLY_ENCLOSED_BY = ? ESCAPE_UNENCLOSED_FIELD = ? FIELD_DELIMITER = ? )`,
 			statementMetadata: StatementMetadata{
-				Tables:     []string{"REPORTING.GENERAL.MY_TABLE", "@REPORTING.GENERAL.SOME_DESCRIPTIONS/external_data/"},
+				Tables:     []string{"REPORTING.GENERAL.MY_TABLE"},
 				Comments:   []string{},
 				Commands:   []string{"SELECT"},
 				Procedures: []string{},
-				Size:       83,
+				Stages:     []string{"@REPORTING.GENERAL.SOME_DESCRIPTIONS"},
+				Size:       68,
 			},
 			lexerOpts: []lexerOption{
 				WithDBMS(DBMSSnowflake),
@@ -511,6 +512,80 @@ multiline comment */
 				WithDBMS("postgres"),
 			},
 		},
+		{
+			input:    `INSERT INTO ks.profiles (id, prefs, tags) VALUES (5d3b4f5e-7a1c-4e2b-9f0a-1b2c3d4e5f60, {'theme': 'dark', 'lang': 'en'}, {'a', 'b'})`,
+			expected: `INSERT INTO ks.profiles ( id, prefs, tags ) VALUES ( ?, { ? }, { ? } )`,
+			statementMetadata: StatementMetadata{
+				Tables:     []string{"ks.profiles"},
+				Comments:   []string{},
+				Commands:   []string{"INSERT"},
+				Procedures: []string{},
+				Size:       17,
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSCassandra),
+			},
+		},
+		{
+			input:    "SELECT name FROM customers WHERE id = 1\nGO\nUPDATE orders SET status = 'shipped'\nGO 2",
+			expected: "SELECT name FROM customers WHERE id = ?; UPDATE orders SET status = ?",
+			statementMetadata: StatementMetadata{
+				Tables:     []string{"customers", "orders"},
+				Comments:   []string{},
+				Commands:   []string{"SELECT", "UPDATE"},
+				Procedures: []string{},
+				Size:       27,
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+				WithScriptMode(true),
+			},
+		},
+		{
+			input:    "CREATE TABLE staging (id NUMBER)\n/\nINSERT INTO staging VALUES (1)\n/",
+			expected: "CREATE TABLE staging ( id NUMBER ); INSERT INTO staging VALUES ( ? )",
+			statementMetadata: StatementMetadata{
+				Tables:     []string{"staging"},
+				Comments:   []string{},
+				Commands:   []string{"CREATE", "INSERT"},
+				Procedures: []string{},
+				Size:       19,
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+				WithScriptMode(true),
+			},
+		},
+		{
+			input:    "DELIMITER $$\nCREATE TRIGGER audit AFTER INSERT ON orders FOR EACH ROW BEGIN INSERT INTO audit_log VALUES (NEW.id); END$$\nDELIMITER ;",
+			expected: "CREATE TRIGGER audit AFTER INSERT ON orders FOR EACH ROW BEGIN INSERT INTO audit_log VALUES ( NEW.id ); END",
+			statementMetadata: StatementMetadata{
+				Tables:     []string{"audit_log"},
+				Comments:   []string{},
+				Commands:   []string{"CREATE", "INSERT", "BEGIN"},
+				Procedures: []string{},
+				Size:       26,
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+				WithScriptMode(true),
+			},
+		},
+		{
+			input:    "\\connect billing\n\\set ON_ERROR_STOP on\nSELECT * FROM invoices WHERE total > 100",
+			expected: "SELECT * FROM invoices WHERE total > ?",
+			statementMetadata: StatementMetadata{
+				Tables:     []string{"invoices"},
+				Comments:   []string{},
+				Commands:   []string{"SELECT"},
+				Procedures: []string{},
+				Size:       14,
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+				WithScriptMode(true),
+			},
+		},
 	}
 
 	obfuscator := NewObfuscator(
@@ -538,3 +613,33 @@ multiline comment */
 		})
 	}
 }
+
+func TestObfuscateAndNormalizeMulti(t *testing.T) {
+	input := "UPDATE accounts SET balance = 100 WHERE id = 7\nGO\nSELECT * FROM #recent WITH (NOLOCK) WHERE name = 'bob'"
+	obfuscator := NewObfuscator(WithReplaceDigits(true))
+	normalizer := NewNormalizer(
+		WithCollectCommands(true),
+		WithCollectTables(true),
+		WithMergeStatements(true),
+	)
+
+	result, err := ObfuscateAndNormalizeMulti(input, obfuscator, normalizer, WithDBMS(DBMSSQLServer), WithScriptMode(true))
+	assert.NoError(t, err)
+	assert.Len(t, result.Statements, 2)
+
+	update := result.Statements[0]
+	assert.Equal(t, "UPDATE accounts SET balance = ? WHERE id = ?", update.NormalizedSQL)
+	assert.Equal(t, input[update.Start:update.End], update.Text)
+	assert.Equal(t, []string{"accounts"}, update.StatementMetadata.Tables)
+	assert.Equal(t, []string{"UPDATE"}, update.StatementMetadata.Commands)
+
+	selectStatement := result.Statements[1]
+	assert.Equal(t, "SELECT * FROM #recent WITH ( NOLOCK ) WHERE name = ?", selectStatement.NormalizedSQL)
+	assert.Equal(t, 50, selectStatement.Start)
+	assert.Equal(t, []string{"#recent"}, selectStatement.StatementMetadata.TempTables)
+	assert.Equal(t, []string{"NOLOCK"}, selectStatement.StatementMetadata.TableHints)
+
+	assert.Equal(t, "UPDATE accounts SET balance = ? WHERE id = ?; SELECT * FROM #recent WITH ( NOLOCK ) WHERE name = ?", result.Merged.NormalizedSQL)
+	assert.Equal(t, []string{"UPDATE", "SELECT"}, result.Merged.StatementMetadata.Commands)
+	assert.Equal(t, []string{"#recent"}, result.Merged.StatementMetadata.TempTables)
+}
@@ -12,6 +12,9 @@ type obfuscatorConfig struct {
 	ReplaceNull                bool `json:"replace_null"`
 	KeepJsonPath               bool `json:"keep_json_path"` // by default, we replace json path with placeholder
 	ReplaceBindParameter       bool `json:"replace_bind_parameter"`
+	ReplaceSubstitutionVar     bool `json:"replace_substitution_var"`
+	KeepFilePath               bool `json:"keep_file_path"` // by default, we replace file paths used as tables with placeholder, so they are not collected as metadata either
+	EmbeddedSQL                bool `json:"embedded_sql"`   // obfuscate SQL held in string literals, e.g. EXECUTE IMMEDIATE '...'
 }
 
 type obfuscatorOption func(*obfuscatorConfig)
@@ -58,6 +61,26 @@ func WithReplaceBindParameter(replaceBindParameter bool) obfuscatorOption {
 	}
 }
 
+func WithEmbeddedSQL(embeddedSQL bool) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.EmbeddedSQL = embeddedSQL
+	}
+}
+
+func WithReplaceSubstitutionVar(replaceSubstitutionVar bool) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.ReplaceSubstitutionVar = replaceSubstitutionVar
+	}
+}
+
+// WithKeepFilePath keeps the file paths used as tables, e.g. 'data/*.parquet' in DuckDB.
+// When ObfuscateAndNormalize replaces them, they are not reported in StatementMetadata.FilePaths
+func WithKeepFilePath(keepFilePath bool) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.KeepFilePath = keepFilePath
+	}
+}
+
 type Obfuscator struct {
 	config *obfuscatorConfig
 }
@@ -89,6 +112,7 @@ func (o *Obfuscator) Obfuscate(input string, lexerOpts ...lexerOption) string {
 		input,
 		lexerOpts...,
 	)
+	lexer.embeddedSQL.enabled = o.config.EmbeddedSQL
 
 	var lastValueToken *LastValueToken
 
@@ -114,6 +138,8 @@ func (o *Obfuscator) ObfuscateTokenValue(token *Token, lastValueToken *LastValue
 			break
 		}
 		token.Value = NumberPlaceholder
+	case UUID, COLLECTION_LITERAL:
+		token.Value = StringPlaceholder
 	case DOLLAR_QUOTED_FUNCTION:
 		if o.config.DollarQuotedFunc {
 			// obfuscate the content of dollar quoted function
@@ -127,6 +153,13 @@ func (o *Obfuscator) ObfuscateTokenValue(token *Token, lastValueToken *LastValue
 			break
 		}
 		token.Value = StringPlaceholder
+	case EMBEDDED_SQL:
+		if o.config.EmbeddedSQL {
+			// obfuscate the SQL statement held by the string literal
+			token.Value = quoteEmbeddedSQL(o.Obfuscate(unquoteEmbeddedSQL(token.Value), lexerOpts...))
+			break
+		}
+		token.Value = StringPlaceholder
 	case STRING, INCOMPLETE_STRING, DOLLAR_QUOTED_STRING:
 		if o.config.KeepJsonPath && lastValueToken != nil && lastValueToken.Type == JSON_OP {
 			break
@@ -140,6 +173,21 @@ func (o *Obfuscator) ObfuscateTokenValue(token *Token, lastValueToken *LastValue
 		if o.config.ReplaceBindParameter {
 			token.Value = StringPlaceholder
 		}
+	case SUBSTITUTION_VARIABLE:
+		if o.config.ReplaceSubstitutionVar {
+			token.Value = StringPlaceholder
+		}
+	case FILE_PATH:
+		if o.config.KeepFilePath {
+			break
+		}
+		if len(token.quotes) > 0 {
+			// keep the data source format and obfuscate the path, e.g. delta.?
+			token.Value = token.Value[:token.quotes[0]] + StringPlaceholder
+			token.quotes = nil
+			break
+		}
+		token.Value = StringPlaceholder
 	case BOOLEAN:
 		if o.config.ReplaceBoolean {
 			token.Value = StringPlaceholder
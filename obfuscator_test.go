@@ -18,6 +18,8 @@ func TestObfuscator(t *testing.T) {
 		dollarQuotedFunc           bool
 		keepJsonPath               bool
 		replaceBindParameter       bool
+		replaceSubstitutionVar     bool
+		embeddedSQL                bool
 		dbms                       DBMSType
 	}{
 		{
@@ -551,6 +553,34 @@ func TestObfuscator(t *testing.T) {
 			expected:             `SELECT * FROM users where id = ?`,
 			replaceBindParameter: true,
 		},
+		{
+			input:    "SELECT * FROM delta.`/mnt/datalake/events` WHERE dt = ${run_date}",
+			expected: "SELECT * FROM delta.? WHERE dt = ${run_date}",
+			dbms:     DBMSSparkSQL,
+		},
+		{
+			input:                  "SELECT * FROM delta.`/mnt/datalake/events` WHERE dt = ${run_date}",
+			expected:               "SELECT * FROM delta.? WHERE dt = ?",
+			replaceSubstitutionVar: true,
+			dbms:                   DBMSSparkSQL,
+		},
+		{
+			input:    "EXEC sp_executesql N'SELECT * FROM users WHERE id = @p0', N'@p0 int', @p0 = 42",
+			expected: "EXEC sp_executesql N?, N?, @p0 = ?",
+			dbms:     DBMSSQLServer,
+		},
+		{
+			input:       "EXEC sp_executesql N'SELECT * FROM users WHERE name = ''bob''', N'@p0 int', @p0 = 42",
+			expected:    "EXEC sp_executesql N'SELECT * FROM users WHERE name = ?', N?, @p0 = ?",
+			embeddedSQL: true,
+			dbms:        DBMSSQLServer,
+		},
+		{
+			input:       "EXECUTE IMMEDIATE 'DELETE FROM t WHERE id = 1'",
+			expected:    "EXECUTE IMMEDIATE 'DELETE FROM t WHERE id = ?'",
+			embeddedSQL: true,
+			dbms:        DBMSOracle,
+		},
 	}
 
 	for _, tt := range tests {
@@ -563,6 +593,8 @@ func TestObfuscator(t *testing.T) {
 				WithDollarQuotedFunc(tt.dollarQuotedFunc),
 				WithKeepJsonPath(tt.keepJsonPath),
 				WithReplaceBindParameter(tt.replaceBindParameter),
+				WithReplaceSubstitutionVar(tt.replaceSubstitutionVar),
+				WithEmbeddedSQL(tt.embeddedSQL),
 			)
 			got := obfuscator.Obfuscate(tt.input, WithDBMS(tt.dbms))
 			assert.Equal(t, tt.expected, got)
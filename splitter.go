@@ -0,0 +1,315 @@
+package sqllexer
+
+import "strings"
+
+// Statement is a single statement of a SQL script
+type Statement struct {
+	Text     string // the statement text, without the terminating semicolon
+	Start    int    // byte offset of the statement in the input
+	End      int    // byte offset just past the statement in the input, input[Start:End] == Text
+	Database string // database selected by the last \connect directive or USE statement before the statement, if any
+}
+
+// blockEndSuffixes are the keywords following END that close a control statement
+// rather than a BEGIN ... END block, e.g. END IF or END LOOP
+var blockEndSuffixes = map[string]struct{}{
+	"IF":     {},
+	"LOOP":   {},
+	"WHILE":  {},
+	"REPEAT": {},
+	"FOR":    {},
+}
+
+// transactionBeginSuffixes are the keywords following BEGIN that start a transaction
+// rather than a compound statement, e.g. BEGIN TRANSACTION or BEGIN WORK
+var transactionBeginSuffixes = map[string]struct{}{
+	"TRANSACTION": {},
+	"TRAN":        {},
+	"WORK":        {},
+	"DISTRIBUTED": {},
+	"ISOLATION":   {},
+	"READ":        {},
+	"DEFERRED":    {},
+	"IMMEDIATE":   {},
+	"EXCLUSIVE":   {},
+}
+
+// statementSplitter tracks the block structure of the current statement
+// to tell a statement terminator from a semicolon inside a compound statement
+type statementSplitter struct {
+	dbms         DBMSType
+	blocks       []bool // open blocks, true while a declaration section still awaits its BEGIN
+	parens       int    // depth of open parentheses
+	pendingBegin bool   // BEGIN seen, the next token tells a block from a transaction
+	pendingEnd   bool   // END seen, the next token tells the end of a block from END IF, END LOOP, ...
+	expectBody   bool   // PL/SQL subprogram or package header seen, IS or AS opens its body
+	words        int    // number of value tokens in the current statement
+	command      string // first word of the current statement
+	untilBatch   bool   // T-SQL module body, which runs to the end of the batch
+	useTarget    string // second word of a USE statement, e.g. DATABASE in USE DATABASE db in Snowflake
+	useDatabase  string // database selected by the current USE statement
+}
+
+// useObjectKinds are the objects other than databases a USE statement can select, e.g. USE WAREHOUSE wh in Snowflake
+var useObjectKinds = map[string]struct{}{
+	"SCHEMA":    {},
+	"WAREHOUSE": {},
+	"ROLE":      {},
+	"SECONDARY": {},
+	"CATALOG":   {},
+}
+
+// SplitStatements splits a SQL script into its statements.
+// Statements are terminated by semicolons, except for semicolons in strings, comments,
+// dollar quoted bodies, parentheses, compound statements (BEGIN ... END, PL/SQL blocks) and CASE ... END.
+// Statements made of comments only are dropped.
+// With WithScriptMode, client-side directives end the current statement and are not returned,
+// and a delimiter set by a MySQL DELIMITER directive replaces the semicolon.
+func SplitStatements(input string, lexerOpts ...lexerOption) []Statement {
+	lexer := New(input, lexerOpts...)
+	splitter := &statementSplitter{dbms: lexer.config.DBMS}
+
+	var statements []Statement
+	start, end := -1, -1
+	hasValue := false
+	database := ""
+
+	flush := func() {
+		if hasValue {
+			statements = append(statements, Statement{Text: input[start:end], Start: start, End: end, Database: database})
+			if splitter.useDatabase != "" {
+				database = splitter.useDatabase
+			}
+		}
+		start, end = -1, -1
+		hasValue = false
+		splitter.reset()
+	}
+
+	for {
+		token := lexer.Scan()
+		if token.Type == EOF {
+			break
+		}
+		tokenStart := lexer.cursor - len(token.Value)
+		if token.Type == ERROR {
+			// unterminated literal or identifier, the rest of the input belongs to the current statement
+			if start < 0 {
+				start = tokenStart
+			}
+			end = len(input)
+			hasValue = true
+			break
+		}
+		if token.Type == SPACE {
+			continue
+		}
+		if token.Type == CLIENT_DIRECTIVE {
+			// directives are not SQL, e.g. GO ends a batch and \connect switches databases
+			flush()
+			if db, ok := connectDatabase(token.Value); ok {
+				database = db
+			}
+			continue
+		}
+		if isValueToken(token) {
+			splitter.update(token)
+			if splitter.isTerminator(token, lexer) {
+				flush()
+				continue
+			}
+			hasValue = true
+		}
+		if start < 0 {
+			start = tokenStart
+		}
+		end = lexer.cursor
+	}
+	flush()
+
+	return statements
+}
+
+func (sp *statementSplitter) reset() {
+	*sp = statementSplitter{dbms: sp.dbms, blocks: sp.blocks[:0]}
+}
+
+// isTerminator reports whether a token ends the current statement
+func (sp *statementSplitter) isTerminator(token *Token, lexer *Lexer) bool {
+	if token.Type != PUNCTUATION {
+		return false
+	}
+	if lexer.delimiter != "" {
+		// the client splits on a custom delimiter regardless of the block structure
+		return lexer.isDelimiter(token.Value)
+	}
+	return token.Value == ";" && len(sp.blocks) == 0 && sp.parens == 0 && !sp.untilBatch
+}
+
+func (sp *statementSplitter) update(token *Token) {
+	word := splitterWord(token)
+
+	if sp.pendingBegin {
+		sp.pendingBegin = false
+		if _, ok := transactionBeginSuffixes[word]; !ok && token.Value != ";" {
+			sp.openBlock()
+		}
+	}
+	if sp.pendingEnd {
+		sp.pendingEnd = false
+		if _, ok := blockEndSuffixes[word]; ok && sp.dbms != DBMSSQLServer {
+			// END IF, END LOOP, ... close a control statement, which does not open a block
+			sp.words++
+			return
+		}
+		if len(sp.blocks) > 0 {
+			sp.blocks = sp.blocks[:len(sp.blocks)-1]
+		}
+		if word == "CASE" {
+			// END CASE closes a CASE statement
+			sp.words++
+			return
+		}
+	}
+
+	if sp.words == 0 {
+		sp.command = word
+	}
+	sp.words++
+
+	if sp.command == "USE" {
+		sp.collectUseDatabase(token, word)
+	}
+
+	switch word {
+	case "BEGIN":
+		sp.pendingBegin = true
+	case "END":
+		sp.pendingEnd = true
+	case "CASE":
+		sp.blocks = append(sp.blocks, false)
+	case "DECLARE":
+		if sp.dbms == DBMSOracle || sp.dbms == DBMSSnowflake {
+			// declaration section of an anonymous block or trigger
+			sp.blocks = append(sp.blocks, true)
+		}
+	case "PROCEDURE", "PROC", "FUNCTION", "TRIGGER":
+		if sp.dbms == DBMSSQLServer && (sp.command == "CREATE" || sp.command == "ALTER") && sp.words <= 4 {
+			// CREATE [OR ALTER] PROCEDURE must be the only statement of its batch
+			sp.untilBatch = true
+		}
+		if sp.dbms == DBMSOracle && word != "TRIGGER" && word != "PROC" && (sp.inPLSQLHeader() || sp.inDeclarationSection()) {
+			sp.expectBody = true
+		}
+	case "PACKAGE", "BODY":
+		if sp.dbms == DBMSOracle && sp.inPLSQLHeader() {
+			sp.expectBody = true
+		}
+	case "IS", "AS":
+		if sp.expectBody && sp.parens == 0 {
+			// the declaration section of a PL/SQL subprogram or package
+			sp.expectBody = false
+			sp.blocks = append(sp.blocks, true)
+		}
+	}
+
+	if token.Type == PUNCTUATION {
+		switch token.Value {
+		case "(":
+			sp.parens++
+		case ")":
+			if sp.parens > 0 {
+				sp.parens--
+			}
+		case ";":
+			sp.expectBody = false
+		}
+	}
+}
+
+// collectUseDatabase records the database selected by a USE statement, e.g. USE db or USE DATABASE db
+func (sp *statementSplitter) collectUseDatabase(token *Token, word string) {
+	switch sp.words {
+	case 2:
+		sp.useTarget = word
+		if _, ok := useObjectKinds[word]; ok || word == "DATABASE" {
+			return
+		}
+	case 3:
+		if sp.useTarget != "DATABASE" {
+			return
+		}
+	default:
+		return
+	}
+	if token.Type == IDENT || token.Type == QUOTED_IDENT {
+		// trim a copy, trimQuotes drops the quote indexes of the token
+		quoted := *token
+		sp.useDatabase = trimQuotes(&quoted)
+	}
+}
+
+// connectDatabase returns the database a psql \connect directive switches to,
+// e.g. db in \c db, \connect -reuse-previous=on db or \c postgresql://host/db
+func connectDatabase(directive string) (string, bool) {
+	fields := strings.Fields(directive)
+	if len(fields) < 2 || (fields[0] != "\\c" && fields[0] != "\\connect") {
+		return "", false
+	}
+	arg := fields[1]
+	if strings.HasPrefix(arg, "-reuse-previous") && len(fields) > 2 {
+		arg = fields[2]
+	}
+	if arg == "-" {
+		// keep the current database
+		return "", false
+	}
+	if i := strings.Index(arg, "://"); i >= 0 {
+		// connection URI, the database is the path
+		arg = arg[i+3:]
+		slash := strings.IndexByte(arg, '/')
+		if slash < 0 {
+			return "", false
+		}
+		arg = arg[slash+1:]
+		if end := strings.IndexByte(arg, '?'); end >= 0 {
+			arg = arg[:end]
+		}
+	} else if i := strings.Index(arg, "dbname="); i >= 0 {
+		// connection string, e.g. 'dbname=db host=h'
+		arg = strings.Trim(arg[i+len("dbname="):], "'")
+	}
+	arg = strings.Trim(arg, "\"'")
+	return arg, arg != ""
+}
+
+// openBlock opens the block of a BEGIN, unless it is the body of a pending declaration section
+// inPLSQLHeader reports whether the current word belongs to the header of a CREATE or ALTER statement,
+// e.g. PACKAGE BODY in CREATE OR REPLACE EDITIONABLE PACKAGE BODY pkg
+func (sp *statementSplitter) inPLSQLHeader() bool {
+	return (sp.command == "CREATE" || sp.command == "ALTER") && sp.words <= 6
+}
+
+// inDeclarationSection reports whether the innermost block is a declaration section,
+// where subprograms are declared, e.g. PROCEDURE p IS ... in a package body
+func (sp *statementSplitter) inDeclarationSection() bool {
+	n := len(sp.blocks)
+	return n > 0 && sp.blocks[n-1]
+}
+
+func (sp *statementSplitter) openBlock() {
+	if n := len(sp.blocks); n > 0 && sp.blocks[n-1] {
+		sp.blocks[n-1] = false
+		return
+	}
+	sp.blocks = append(sp.blocks, false)
+}
+
+// splitterWord returns the upper cased value of a keyword or identifier token
+func splitterWord(token *Token) string {
+	switch token.Type {
+	case IDENT, KEYWORD, COMMAND, ALIAS_INDICATOR, PROC_INDICATOR:
+		return strings.ToUpper(token.Value)
+	}
+	return ""
+}
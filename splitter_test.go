@@ -0,0 +1,302 @@
+package sqllexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  []string
+		lexerOpts []lexerOption
+	}{
+		{
+			name:     "single statement",
+			input:    "SELECT * FROM users",
+			expected: []string{"SELECT * FROM users"},
+		},
+		{
+			name:     "multiple statements",
+			input:    "SELECT 1; SELECT 2;\nDELETE FROM users;",
+			expected: []string{"SELECT 1", "SELECT 2", "DELETE FROM users"},
+		},
+		{
+			name:     "empty statements and comment only statements",
+			input:    ";; SELECT 1;  ; -- trailing comment",
+			expected: []string{"SELECT 1"},
+		},
+		{
+			name:     "leading comments belong to the statement",
+			input:    "/* first */ SELECT 1; -- second\nSELECT 2",
+			expected: []string{"/* first */ SELECT 1", "-- second\nSELECT 2"},
+		},
+		{
+			name:     "semicolons in strings and comments",
+			input:    "SELECT 'a;b', \"c;d\" FROM t /* ; */; -- ;\nSELECT 2",
+			expected: []string{"SELECT 'a;b', \"c;d\" FROM t /* ; */", "-- ;\nSELECT 2"},
+		},
+		{
+			name:     "CASE expression",
+			input:    "SELECT CASE WHEN a = 1 THEN 'x' ELSE 'y' END FROM t; SELECT 2",
+			expected: []string{"SELECT CASE WHEN a = 1 THEN 'x' ELSE 'y' END FROM t", "SELECT 2"},
+		},
+		{
+			name:     "unterminated string",
+			input:    "SELECT 1; SELECT 'abc; SELECT 2",
+			expected: []string{"SELECT 1", "SELECT 'abc; SELECT 2"},
+		},
+		{
+			name:      "postgres dollar quoted function body",
+			input:     "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql; SELECT f();",
+			expected:  []string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT f()"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "postgres DO block",
+			input:     "DO $body$ BEGIN PERFORM 1; END $body$; VACUUM users",
+			expected:  []string{"DO $body$ BEGIN PERFORM 1; END $body$", "VACUUM users"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "postgres transaction",
+			input:     "BEGIN; UPDATE users SET a = 1; END;",
+			expected:  []string{"BEGIN", "UPDATE users SET a = 1", "END"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "postgres BEGIN ATOMIC function body",
+			input:     "CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT 1; SELECT 2; END; SELECT 3",
+			expected:  []string{"CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT 1; SELECT 2; END", "SELECT 3"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "postgres rule with multiple actions",
+			input:     "CREATE RULE r AS ON INSERT TO t DO ALSO (INSERT INTO a VALUES (1); INSERT INTO b VALUES (2)); SELECT 1",
+			expected:  []string{"CREATE RULE r AS ON INSERT TO t DO ALSO (INSERT INTO a VALUES (1); INSERT INTO b VALUES (2))", "SELECT 1"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name: "mysql stored procedure",
+			input: `CREATE PROCEDURE p(IN n INT)
+BEGIN
+  DECLARE i INT DEFAULT 0;
+  IF n > 0 THEN
+    SET i = n;
+  END IF;
+  WHILE i > 0 DO
+    SET i = i - 1;
+  END WHILE;
+  CASE i WHEN 0 THEN SELECT 'zero'; ELSE SELECT 'other'; END CASE;
+END;
+CALL p(3);`,
+			expected: []string{`CREATE PROCEDURE p(IN n INT)
+BEGIN
+  DECLARE i INT DEFAULT 0;
+  IF n > 0 THEN
+    SET i = n;
+  END IF;
+  WHILE i > 0 DO
+    SET i = i - 1;
+  END WHILE;
+  CASE i WHEN 0 THEN SELECT 'zero'; ELSE SELECT 'other'; END CASE;
+END`, "CALL p(3)"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:      "mysql labeled loop in trigger",
+			input:     "CREATE TRIGGER t BEFORE INSERT ON users FOR EACH ROW lbl: BEGIN LOOP LEAVE lbl; END LOOP; END lbl; START TRANSACTION; BEGIN WORK;",
+			expected:  []string{"CREATE TRIGGER t BEFORE INSERT ON users FOR EACH ROW lbl: BEGIN LOOP LEAVE lbl; END LOOP; END lbl", "START TRANSACTION", "BEGIN WORK"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:      "sql server try catch",
+			input:     "BEGIN TRY BEGIN TRANSACTION; UPDATE t SET a = 1; COMMIT; END TRY BEGIN CATCH ROLLBACK; END CATCH; SELECT 1",
+			expected:  []string{"BEGIN TRY BEGIN TRANSACTION; UPDATE t SET a = 1; COMMIT; END TRY BEGIN CATCH ROLLBACK; END CATCH", "SELECT 1"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name:      "sql server END followed by IF",
+			input:     "IF @a = 1 BEGIN SELECT 1; END IF @b = 1 BEGIN SELECT 2; END; SELECT 3",
+			expected:  []string{"IF @a = 1 BEGIN SELECT 1; END IF @b = 1 BEGIN SELECT 2; END", "SELECT 3"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name:      "sql server procedure body runs to the end of the batch",
+			input:     "CREATE OR ALTER PROCEDURE dbo.p AS SELECT 1; SELECT 2;",
+			expected:  []string{"CREATE OR ALTER PROCEDURE dbo.p AS SELECT 1; SELECT 2;"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name: "oracle anonymous block",
+			input: `DECLARE
+  v NUMBER;
+BEGIN
+  SELECT COUNT(*) INTO v FROM users;
+  IF v > 0 THEN
+    DBMS_OUTPUT.PUT_LINE('found');
+  END IF;
+  FOR r IN (SELECT id FROM users) LOOP
+    NULL;
+  END LOOP;
+END;
+SELECT 1 FROM dual`,
+			expected: []string{`DECLARE
+  v NUMBER;
+BEGIN
+  SELECT COUNT(*) INTO v FROM users;
+  IF v > 0 THEN
+    DBMS_OUTPUT.PUT_LINE('found');
+  END IF;
+  FOR r IN (SELECT id FROM users) LOOP
+    NULL;
+  END LOOP;
+END`, "SELECT 1 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle procedure with declarations",
+			input:     "CREATE OR REPLACE PROCEDURE p (a IN NUMBER) IS v NUMBER; CURSOR c IS SELECT 1 FROM dual; BEGIN NULL; END p; SELECT 2 FROM dual",
+			expected:  []string{"CREATE OR REPLACE PROCEDURE p (a IN NUMBER) IS v NUMBER; CURSOR c IS SELECT 1 FROM dual; BEGIN NULL; END p", "SELECT 2 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle package specification",
+			input:     "CREATE PACKAGE pkg AS PROCEDURE p; FUNCTION f RETURN NUMBER; END pkg; SELECT 1 FROM dual",
+			expected:  []string{"CREATE PACKAGE pkg AS PROCEDURE p; FUNCTION f RETURN NUMBER; END pkg", "SELECT 1 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle package body",
+			input:     "CREATE PACKAGE BODY pkg AS PROCEDURE p IS BEGIN NULL; END p; FUNCTION f RETURN NUMBER IS BEGIN RETURN 1; END f; BEGIN NULL; END pkg; SELECT 1 FROM dual",
+			expected:  []string{"CREATE PACKAGE BODY pkg AS PROCEDURE p IS BEGIN NULL; END p; FUNCTION f RETURN NUMBER IS BEGIN RETURN 1; END f; BEGIN NULL; END pkg", "SELECT 1 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle columns named body and function",
+			input:     "SELECT body AS b FROM posts; SELECT * FROM t WHERE body IS NULL; SELECT function AS f FROM t; SELECT 2 FROM dual",
+			expected:  []string{"SELECT body AS b FROM posts", "SELECT * FROM t WHERE body IS NULL", "SELECT function AS f FROM t", "SELECT 2 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle view and type are not blocks",
+			input:     "CREATE VIEW v AS SELECT 1 FROM dual; CREATE TYPE t AS OBJECT (a NUMBER); SELECT 2 FROM dual",
+			expected:  []string{"CREATE VIEW v AS SELECT 1 FROM dual", "CREATE TYPE t AS OBJECT (a NUMBER)", "SELECT 2 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "oracle trigger with declarations",
+			input:     "CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW DECLARE v NUMBER; BEGIN :NEW.id := 1; END; SELECT 1 FROM dual",
+			expected:  []string{"CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW DECLARE v NUMBER; BEGIN :NEW.id := 1; END", "SELECT 1 FROM dual"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:      "snowflake scripting block",
+			input:     "DECLARE c INTEGER DEFAULT 0; BEGIN FOR i IN 1 TO 3 DO c := c + 1; END FOR; RETURN c; END; BEGIN TRANSACTION; SELECT 1",
+			expected:  []string{"DECLARE c INTEGER DEFAULT 0; BEGIN FOR i IN 1 TO 3 DO c := c + 1; END FOR; RETURN c; END", "BEGIN TRANSACTION", "SELECT 1"},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSnowflake)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := SplitStatements(tt.input, tt.lexerOpts...)
+			texts := make([]string, 0, len(statements))
+			for _, statement := range statements {
+				// the byte range must point back at the statement text
+				assert.Equal(t, statement.Text, tt.input[statement.Start:statement.End])
+				texts = append(texts, statement.Text)
+			}
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestSplitStatementsByteRange(t *testing.T) {
+	input := "SELECT 1;\n  UPDATE users SET name = 'é' ;"
+	statements := SplitStatements(input)
+	assert.Equal(t, []Statement{
+		{Text: "SELECT 1", Start: 0, End: 8},
+		{Text: "UPDATE users SET name = 'é'", Start: 12, End: 40},
+	}, statements)
+}
+
+func TestSplitStatementsScriptMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  []Statement
+		lexerOpts []lexerOption
+	}{
+		{
+			name:  "mysql DELIMITER",
+			input: "DELIMITER $$\nCREATE PROCEDURE p() BEGIN SELECT 1; END$$\nDELIMITER ;\nCALL p();",
+			expected: []Statement{
+				{Text: "CREATE PROCEDURE p() BEGIN SELECT 1; END", Start: 13, End: 53},
+				{Text: "CALL p()", Start: 68, End: 76},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL), WithScriptMode(true)},
+		},
+		{
+			name:  "mysql custom delimiter splits regardless of blocks",
+			input: "DELIMITER //\nSELECT 1; SELECT 2//\nUSE `shop`//\nSELECT 3//",
+			expected: []Statement{
+				{Text: "SELECT 1; SELECT 2", Start: 13, End: 31},
+				{Text: "USE `shop`", Start: 34, End: 44},
+				{Text: "SELECT 3", Start: 47, End: 55, Database: "shop"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL), WithScriptMode(true)},
+		},
+		{
+			name:  "sqlcmd GO ends a batch",
+			input: "USE [sales]\nGO\nCREATE PROCEDURE p AS SELECT 1; SELECT 2;\nGO 2\nEXEC p",
+			expected: []Statement{
+				{Text: "USE [sales]", Start: 0, End: 11},
+				{Text: "CREATE PROCEDURE p AS SELECT 1; SELECT 2;", Start: 15, End: 56, Database: "sales"},
+				{Text: "EXEC p", Start: 62, End: 68, Database: "sales"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer), WithScriptMode(true)},
+		},
+		{
+			name:  "SQL*Plus slash and SET commands",
+			input: "SET SERVEROUTPUT ON\nBEGIN\n  NULL;\nEND;\n/\nCREATE TYPE t AS OBJECT (a NUMBER);\n/\nSELECT 1 FROM dual",
+			expected: []Statement{
+				{Text: "BEGIN\n  NULL;\nEND", Start: 20, End: 37},
+				{Text: "CREATE TYPE t AS OBJECT (a NUMBER)", Start: 41, End: 75},
+				{Text: "SELECT 1 FROM dual", Start: 79, End: 97},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle), WithScriptMode(true)},
+		},
+		{
+			name:  "psql meta-commands",
+			input: "\\connect analytics\nSELECT 1;\n\\set id 42\n\\c 'postgresql://db.local:5432/billing?sslmode=require'\nSELECT 2;",
+			expected: []Statement{
+				{Text: "SELECT 1", Start: 19, End: 27, Database: "analytics"},
+				{Text: "SELECT 2", Start: 96, End: 104, Database: "billing"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres), WithScriptMode(true)},
+		},
+		{
+			name:  "snowflake USE DATABASE",
+			input: "USE WAREHOUSE wh; USE DATABASE \"Prod\"; SELECT 1",
+			expected: []Statement{
+				{Text: "USE WAREHOUSE wh", Start: 0, End: 16},
+				{Text: "USE DATABASE \"Prod\"", Start: 18, End: 37},
+				{Text: "SELECT 1", Start: 39, End: 47, Database: "Prod"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSnowflake), WithScriptMode(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := SplitStatements(tt.input, tt.lexerOpts...)
+			assert.Equal(t, tt.expected, statements)
+			for _, statement := range statements {
+				assert.Equal(t, statement.Text, tt.input[statement.Start:statement.End])
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package sqllexer
 
 import (
+	"strings"
 	"unicode/utf8"
 )
 
@@ -35,6 +36,17 @@ const (
 	PROC_INDICATOR         // procedure indicator
 	CTE_INDICATOR          // CTE indicator
 	ALIAS_INDICATOR        // alias indicator
+	SUBSTITUTION_VARIABLE  // substitution variable, e.g. ${var} or ${hivevar:x}
+	FILE_PATH              // file path used as a table, e.g. delta.`/mnt/path`
+	INDEX_HINT             // index hint attached to a table, e.g. @users_email_idx in CockroachDB
+	UUID                   // uuid literal, e.g. 123e4567-e89b-12d3-a456-426614174000 in Cassandra
+	COLLECTION_LITERAL     // collection literal, e.g. <<1, 2>> or {'pk': 'x'} in PartiQL
+	FEATURE_COMMENT        // opening or closing marker of an executable comment, e.g. /*T![clustered_index] or */ in TiDB
+	VARIANT_PATH           // semi-structured path access, e.g. :customer.name in Snowflake v:customer.name
+	STAGE                  // stage reference, e.g. @my_stage/path/ or @~ in Snowflake
+	DB_LINK                // database link attached to an object, e.g. @remote_db in Oracle orders@remote_db
+	EMBEDDED_SQL           // string literal holding a SQL statement, e.g. 'SELECT 1' in EXECUTE IMMEDIATE 'SELECT 1'
+	CLIENT_DIRECTIVE       // client-side script directive, e.g. GO in sqlcmd, DELIMITER $$ in MySQL or \connect db in psql
 )
 
 // Token represents a SQL token with its type and value.
@@ -62,7 +74,8 @@ func (t *Token) getLastValueToken() *LastValueToken {
 }
 
 type LexerConfig struct {
-	DBMS DBMSType `json:"dbms,omitempty"`
+	DBMS       DBMSType `json:"dbms,omitempty"`
+	ScriptMode bool     `json:"script_mode,omitempty"`
 }
 
 type lexerOption func(*LexerConfig)
@@ -74,6 +87,15 @@ func WithDBMS(dbms DBMSType) lexerOption {
 	}
 }
 
+// WithScriptMode recognizes the client-side directives of scripts run by command line clients,
+// e.g. DELIMITER in MySQL, GO in sqlcmd, a lone slash in SQL*Plus and meta-commands in psql.
+// The normalizer separates the statements before and after a directive with a semicolon
+func WithScriptMode(scriptMode bool) lexerOption {
+	return func(c *LexerConfig) {
+		c.ScriptMode = scriptMode
+	}
+}
+
 type trieNode struct {
 	children         map[rune]*trieNode
 	isEnd            bool
@@ -88,9 +110,104 @@ type Lexer struct {
 	start            int    // the start position of the current token
 	config           *LexerConfig
 	token            *Token
-	digits           []int // Indexes of digits in the token
-	quotes           []int // Indexes of quotes in the token
-	isTableIndicator bool  // true if the token is a table indicator
+	digits           []int  // Indexes of digits in the token
+	quotes           []int  // Indexes of quotes in the token
+	isTableIndicator bool   // true if the token is a table indicator
+	inFeatureComment bool   // true inside an executable comment, e.g. /*T! ... */ in TiDB
+	delimiter        string // statement delimiter set by a DELIMITER directive, empty for the default semicolon
+	duckdb           duckDBState
+	snowflake        snowflakeState
+	embeddedSQL      embeddedSQLState
+	dialectKeywords  []dialectKeyword // the keywords only the dialect recognizes, e.g. UPSERT in CockroachDB
+}
+
+// embeddedSQLState tracks the preceding tokens of string literals that hold SQL statements, e.g.
+// sp_executesql N'...', EXECUTE IMMEDIATE '...' or PREPARE stmt FROM '...'
+type embeddedSQLState struct {
+	enabled      bool // only set when the consumer reads the SQL statements, e.g. the obfuscator with EmbeddedSQL
+	next         bool // the next string literal holds a SQL statement
+	afterPrepare int  // the number of value tokens since PREPARE, 0 if there is none
+}
+
+func (e *embeddedSQLState) update(tok *Token) {
+	if e.next && tok.Type == IDENT && strings.EqualFold(tok.Value, "N") {
+		// unicode string prefix, e.g. N'SELECT 1' in SQL Server
+		return
+	}
+	e.next = false
+	if e.afterPrepare > 0 {
+		e.afterPrepare++
+	}
+	switch tok.Type {
+	case IDENT, KEYWORD, COMMAND:
+		switch {
+		case isEmbeddedSQLProcedure(tok.Value), strings.EqualFold(tok.Value, "IMMEDIATE"):
+			e.next = true
+		case strings.EqualFold(tok.Value, "PREPARE"):
+			e.afterPrepare = 1
+		case e.afterPrepare == 3 && strings.EqualFold(tok.Value, "FROM"):
+			e.next = true
+		}
+	}
+	if e.afterPrepare >= 3 {
+		e.afterPrepare = 0
+	}
+}
+
+// snowflakeState tracks the preceding tokens that change how Snowflake tokens are lexed
+type snowflakeState struct {
+	afterIdentifierFunc bool // the last value token is the IDENTIFIER function
+	identifierNameNext  bool // the next string literal names an object, e.g. IDENTIFIER('db.t')
+}
+
+func (sf *snowflakeState) update(tok *Token) {
+	sf.identifierNameNext = sf.afterIdentifierFunc && tok.Value == "("
+	sf.afterIdentifierFunc = tok.Type == FUNCTION && strings.EqualFold(tok.Value, "IDENTIFIER")
+}
+
+// duckDBState tracks the preceding tokens that change how DuckDB tokens are lexed
+type duckDBState struct {
+	filePathNext      bool // the next string literal is a file path, e.g. FROM 'data/*.parquet'
+	afterFileReader   bool // the last value token is a file reader function, e.g. read_csv_auto
+	inCopyStatement   bool // the current statement is a COPY statement
+	afterStar         bool // the last value tokens are a wildcard, optionally followed by star modifiers
+	afterStarModifier bool // the last value tokens are a star modifier, e.g. * EXCLUDE
+	starModifierDepth int  // parentheses depth inside a star modifier, e.g. * REPLACE (...)
+}
+
+// update updates the DuckDB lexing state with the emitted value token
+func (d *duckDBState) update(tok *Token) {
+	switch {
+	case tok.Type == COMMAND && strings.EqualFold(tok.Value, "COPY"):
+		d.inCopyStatement = true
+	case tok.Value == ";":
+		d.inCopyStatement = false
+	}
+
+	switch {
+	case d.starModifierDepth > 0:
+		if tok.Value == "(" {
+			d.starModifierDepth++
+		} else if tok.Value == ")" {
+			d.starModifierDepth--
+		}
+		// star modifiers can be chained, e.g. * EXCLUDE (a) REPLACE (b AS c)
+		d.afterStar = d.starModifierDepth == 0
+	case d.afterStarModifier && tok.Value == "(":
+		d.afterStarModifier = false
+		d.starModifierDepth = 1
+	case d.afterStar && isStarModifier(tok.Value):
+		d.afterStar = false
+		d.afterStarModifier = true
+	default:
+		d.afterStarModifier = false
+		d.afterStar = tok.Type == WILDCARD
+	}
+
+	d.filePathNext = tok.isTableIndicator ||
+		(d.afterFileReader && tok.Value == "(") ||
+		(d.inCopyStatement && strings.EqualFold(tok.Value, "TO"))
+	d.afterFileReader = tok.Type == FUNCTION && isDuckDBFileReader(tok.Value)
 }
 
 func New(input string, opts ...lexerOption) *Lexer {
@@ -102,6 +219,7 @@ func New(input string, opts ...lexerOption) *Lexer {
 	for _, opt := range opts {
 		opt(lexer.config)
 	}
+	lexer.dialectKeywords = dialectKeywords[lexer.config.DBMS]
 	return lexer
 }
 
@@ -111,6 +229,16 @@ func (s *Lexer) Scan() *Token {
 	switch {
 	case isSpace(ch):
 		return s.scanWhitespace()
+	case s.config.ScriptMode && s.isClientDirectiveAhead():
+		return s.scanClientDirective()
+	case s.isDelimiterAhead():
+		s.start = s.cursor
+		s.nextBy(len(s.delimiter)) // consume the custom delimiter
+		return s.emit(PUNCTUATION)
+	case s.config.DBMS == DBMSCassandra && s.isUUIDAhead():
+		return s.scanUUID()
+	case s.config.DBMS == DBMSSnowflake && s.isLocalFilePathAhead():
+		return s.scanLocalFilePath()
 	case isLetter(ch):
 		return s.scanIdentifier(ch)
 	case isDoubleQuote(ch):
@@ -119,7 +247,19 @@ func (s *Lexer) Scan() *Token {
 		return s.scanString()
 	case isSingleLineComment(ch, s.lookAhead(1)):
 		return s.scanSingleLineComment()
+	case ch == '/' && s.lookAhead(1) == '/' && s.config.DBMS == DBMSCassandra:
+		// CQL also supports C-style single line comments
+		return s.scanSingleLineComment()
+	case s.inFeatureComment && ch == '*' && s.lookAhead(1) == '/':
+		// end of an executable comment
+		s.inFeatureComment = false
+		s.start = s.cursor
+		s.nextBy(2) // consume the closing asterisk and slash
+		return s.emit(FEATURE_COMMENT)
 	case isMultiLineComment(ch, s.lookAhead(1)):
+		if s.isFeatureCommentAhead() {
+			return s.scanFeatureCommentStart()
+		}
 		return s.scanMultiLineComment()
 	case isLeadingSign(ch):
 		// if the leading sign is followed by a digit, then it's a number
@@ -141,26 +281,59 @@ func (s *Lexer) Scan() *Token {
 		if s.config.DBMS == DBMSSQLServer && isLetter(s.lookAhead(1)) {
 			return s.scanIdentifier(ch)
 		}
+		if s.config.DBMS == DBMSSnowflake && isLetter(s.lookAhead(1)) {
+			// session variable, e.g. $table_name
+			return s.scanBindParameter()
+		}
+		if s.config.DBMS == DBMSSparkSQL && s.lookAhead(1) == '{' {
+			return s.scanSubstitutionVariable()
+		}
 		return s.scanDollarQuotedString()
 	case ch == ':':
 		if s.config.DBMS == DBMSOracle && isAlphaNumeric(s.lookAhead(1)) {
 			return s.scanBindParameter()
 		}
+		if s.config.DBMS == DBMSSnowflake && s.isVariantPathAhead() {
+			return s.scanVariantPath()
+		}
+		if (s.config.DBMS == DBMSSparkSQL || s.config.DBMS == DBMSCassandra) && isLetter(s.lookAhead(1)) && isParameterMarkerPosition(s.lookAhead(-1)) {
+			// Databricks and CQL named parameter markers, e.g. :param
+			// but not semi-structured field access, e.g. raw:owner
+			return s.scanBindParameter()
+		}
 		return s.scanOperator(ch)
+	case s.config.DBMS == DBMSPartiQL && (ch == '{' || (ch == '<' && s.lookAhead(1) == '<')):
+		// tuple or bag literal, e.g. {'pk': 'x'} or <<1, 2>>
+		return s.scanCollectionLiteral()
 	case ch == '`':
-		if s.config.DBMS == DBMSMySQL {
+		if isMySQLFamily(s.config.DBMS) || s.config.DBMS == DBMSSparkSQL {
 			return s.scanDoubleQuotedIdentifier('`')
 		}
+		if s.config.DBMS == DBMSPartiQL {
+			// Ion literal, e.g. `{a: 1}`
+			return s.scanIonLiteral()
+		}
 		fallthrough
 	case ch == '#':
 		if s.config.DBMS == DBMSSQLServer {
 			return s.scanIdentifier(ch)
-		} else if s.config.DBMS == DBMSMySQL {
+		} else if isMySQLFamily(s.config.DBMS) {
 			// MySQL treats # as a comment
 			return s.scanSingleLineComment()
 		}
 		return s.scanOperator(ch)
 	case ch == '@':
+		if s.config.DBMS == DBMSSnowflake && isStageStart(s.lookAhead(1)) {
+			return s.scanStage()
+		}
+		if prevCh := s.lookAhead(-1); s.config.DBMS == DBMSCockroachDB && (isAlphaNumeric(prevCh) || prevCh == '"') && isIndexHintStart(s.lookAhead(1)) {
+			// index hint directly following a table name, e.g. users@users_email_idx
+			return s.scanIndexHint()
+		}
+		if prevCh := s.lookAhead(-1); s.config.DBMS == DBMSOracle && (isAlphaNumeric(prevCh) || prevCh == '"') && isLetter(s.lookAhead(1)) {
+			// database link directly following an object name, e.g. orders@remote_db
+			return s.scanDBLink()
+		}
 		if s.lookAhead(1) == '@' {
 			if isAlphaNumeric(s.lookAhead(2)) {
 				return s.scanSystemVariable()
@@ -170,9 +343,6 @@ func (s *Lexer) Scan() *Token {
 			return s.emit(JSON_OP)
 		}
 		if isAlphaNumeric(s.lookAhead(1)) {
-			if s.config.DBMS == DBMSSnowflake {
-				return s.scanIdentifier(ch)
-			}
 			return s.scanBindParameter()
 		}
 		if s.lookAhead(1) == '?' || s.lookAhead(1) == '>' {
@@ -281,7 +451,7 @@ func (s *Lexer) scanNumberic(ch rune) *Token {
 
 func (s *Lexer) scanDecimalNumber(ch rune) *Token {
 	// scan digits
-	for isDigit(ch) || ch == '.' || isExpontent(ch) {
+	for isDigit(ch) || ch == '.' || isExpontent(ch) || s.isDigitSeparator(ch) {
 		if isExpontent(ch) {
 			ch = s.next()
 			if isLeadingSign(ch) {
@@ -294,10 +464,40 @@ func (s *Lexer) scanDecimalNumber(ch rune) *Token {
 	return s.emit(NUMBER)
 }
 
+// isDigitSeparator checks if the rune is a separator between digits, e.g. 1_000 in DuckDB
+func (s *Lexer) isDigitSeparator(ch rune) bool {
+	return ch == '_' && s.config.DBMS == DBMSDuckDB && isDigit(s.lookAhead(1))
+}
+
+// isUUIDAhead checks if a uuid literal starts at the cursor, e.g. 123e4567-e89b-12d3-a456-426614174000
+func (s *Lexer) isUUIDAhead() bool {
+	const uuidLen = 36
+	if s.cursor+uuidLen > len(s.src) || isAlphaNumeric(s.lookAhead(-1)) {
+		return false
+	}
+	for i := 0; i < uuidLen; i++ {
+		ch := rune(s.src[s.cursor+i])
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			if ch != '-' {
+				return false
+			}
+		} else if !isHexDigit(ch) {
+			return false
+		}
+	}
+	return !isIdentifier(s.lookAhead(uuidLen))
+}
+
+func (s *Lexer) scanUUID() *Token {
+	s.start = s.cursor
+	s.nextBy(36) // consume the uuid
+	return s.emit(UUID)
+}
+
 func (s *Lexer) scanHexNumber() *Token {
 	ch := s.nextBy(2) // consume 0x or 0X
 
-	for isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F') {
+	for isHexDigit(ch) {
 		ch = s.next()
 	}
 	return s.emit(NUMBER)
@@ -330,7 +530,24 @@ func (s *Lexer) scanString() *Token {
 		}
 
 		if ch == '\'' {
+			if s.embeddedSQL.next && s.lookAhead(1) == '\'' {
+				// quotes are escaped by doubling them in SQL statements held by string literals
+				s.next()
+				continue
+			}
 			s.next() // consume the closing quote
+			if s.config.DBMS == DBMSDuckDB && s.duckdb.filePathNext {
+				// DuckDB reads files directly, e.g. FROM 'data/*.parquet'
+				return s.emit(FILE_PATH)
+			}
+			if s.embeddedSQL.next {
+				return s.emit(EMBEDDED_SQL)
+			}
+			if s.config.DBMS == DBMSSnowflake && s.snowflake.identifierNameNext {
+				// the object name passed to IDENTIFIER('db.t') is an identifier rather than a value
+				s.quotes = append(s.quotes, 0, s.cursor-s.start-1)
+				return s.emit(QUOTED_IDENT)
+			}
 			return s.emit(STRING)
 		}
 	}
@@ -347,7 +564,7 @@ func (s *Lexer) scanIdentifier(ch rune) *Token {
 
 	// If first character is Unicode, skip trie lookup
 	if ch > 127 {
-		for isIdentifier(ch) {
+		for isIdentifier(ch) && !s.isObjectSuffixStart(ch) && !s.isDelimiterAhead() {
 			if isDigit(ch) {
 				s.digits = append(s.digits, s.cursor-offset)
 			}
@@ -380,34 +597,105 @@ func (s *Lexer) scanIdentifier(ch rune) *Token {
 	}
 
 	// If we found a complete keyword and next char is whitespace
-	if node.isEnd && (isPunctuation(ch) || isSpace(ch) || isEOF(ch)) {
+	if node.isEnd && (isPunctuation(ch) || isSpace(ch) || isEOF(ch) || s.isDelimiterAhead()) {
 		s.cursor = pos + 1 // Include the last matched character
 		s.isTableIndicator = node.isTableIndicator
+		if keyword, ok := s.lookupDialectKeyword(); ok {
+			s.isTableIndicator = keyword.isTableIndicator
+			return s.emit(keyword.tokenType)
+		}
+		if node.tokenType == ALIAS_INDICATOR && s.duckdb.starModifierDepth > 0 {
+			// AS in * REPLACE (expr AS col) names the replaced column rather than an alias
+			return s.emit(KEYWORD)
+		}
 		return s.emit(node.tokenType)
 	}
 
 	// Continue scanning identifier if no keyword match
-	for isIdentifier(ch) {
+	for isIdentifier(ch) && !s.isObjectSuffixStart(ch) && !s.isDelimiterAhead() {
 		if isDigit(ch) {
 			s.digits = append(s.digits, s.cursor-offset)
 		}
 		ch = s.nextBy(utf8.RuneLen(ch))
 	}
 
+	if quote := s.qualifiedNameQuote(); quote != 0 && ch == quote && s.src[s.cursor-1] == '.' {
+		// qualified names quoted piecewise, e.g. hive."web".events in Trino
+		prefix := s.src[s.start : s.cursor-1]
+		if !s.scanQuotedIdentifierPart(quote, offset) {
+			return s.emit(ERROR)
+		}
+		if s.config.DBMS == DBMSSparkSQL && isSparkFileFormat(prefix) {
+			// path-based table, e.g. delta.`/mnt/path`
+			return s.emit(FILE_PATH)
+		}
+		s.scanQualifiedIdentifierParts(quote, offset)
+		return s.emit(QUOTED_IDENT)
+	}
+
+	if keyword, ok := s.lookupDialectKeyword(); ok {
+		s.isTableIndicator = keyword.isTableIndicator
+		return s.emit(keyword.tokenType)
+	}
+
+	if s.config.DBMS == DBMSTeradata {
+		if canonical, ok := lookupKeyword(teradataCommandAbbreviations, s.src[s.start:s.cursor]); ok {
+			// abbreviated commands, e.g. SEL for SELECT
+			s.isTableIndicator = canonical != "SELECT"
+			return s.emit(COMMAND)
+		}
+	}
+
 	if ch == '(' {
 		return s.emit(FUNCTION)
 	}
 	return s.emit(IDENT)
 }
 
+// lookupDialectKeyword returns the keyword of the current dialect matching the token being scanned, if any
+func (s *Lexer) lookupDialectKeyword() (dialectKeyword, bool) {
+	for _, keyword := range s.dialectKeywords {
+		if strings.EqualFold(keyword.word, s.src[s.start:s.cursor]) {
+			return keyword, true
+		}
+	}
+	return dialectKeyword{}, false
+}
+
 func (s *Lexer) scanDoubleQuotedIdentifier(delimiter rune) *Token {
+	s.start = s.cursor
+	offset := s.start // offset is used to calculate the indexes of quotes in the token value
+	if !s.scanQuotedIdentifierPart(delimiter, offset) {
+		return s.emit(ERROR)
+	}
+	if delimiter == s.qualifiedNameQuote() {
+		s.scanQualifiedIdentifierParts(delimiter, offset)
+	}
+	return s.emit(QUOTED_IDENT)
+}
+
+// qualifiedNameQuote returns the quote character with which the dialect allows
+// quoting the parts of a qualified name individually, or 0 if not supported
+func (s *Lexer) qualifiedNameQuote() rune {
+	switch s.config.DBMS {
+	case DBMSTrino, DBMSCassandra, DBMSPostgres, DBMSCockroachDB, DBMSOracle, DBMSSnowflake, DBMSDuckDB, DBMSTeradata:
+		return '"'
+	case DBMSSparkSQL, DBMSMySQL, DBMSMariaDB, DBMSTiDB:
+		return '`'
+	case DBMSSQLServer:
+		return '['
+	}
+	return 0
+}
+
+// scanQuotedIdentifierPart consumes a quoted identifier starting at the cursor,
+// including the closing quote. It returns false if EOF is reached before the closing quote.
+func (s *Lexer) scanQuotedIdentifierPart(delimiter rune, offset int) bool {
 	closingDelimiter := delimiter
 	if delimiter == '[' {
 		closingDelimiter = ']'
 	}
 
-	s.start = s.cursor
-	offset := s.start                            // offset is used to calculate the indexes of quotes in the token value
 	s.quotes = append(s.quotes, s.cursor-offset) // store the opening quote position
 	ch := s.next()                               // consume the opening quote
 	for {
@@ -426,7 +714,7 @@ func (s *Lexer) scanDoubleQuotedIdentifier(delimiter rune) *Token {
 			break
 		}
 		if isEOF(ch) {
-			return s.emit(ERROR)
+			return false
 		}
 		if isDigit(ch) {
 			s.digits = append(s.digits, s.cursor-offset)
@@ -434,7 +722,88 @@ func (s *Lexer) scanDoubleQuotedIdentifier(delimiter rune) *Token {
 		ch = s.next()
 	}
 	s.next() // consume the closing quote
-	return s.emit(QUOTED_IDENT)
+	return true
+}
+
+// scanQualifiedIdentifierParts consumes the remaining parts of a qualified name
+// that mixes quoted and unquoted parts, e.g. "hive".web."events"
+func (s *Lexer) scanQualifiedIdentifierParts(delimiter rune, offset int) {
+	for s.peek() == '.' {
+		nextCh := s.lookAhead(1)
+		if nextCh == delimiter {
+			s.next() // consume the dot
+			if !s.scanQuotedIdentifierPart(delimiter, offset) {
+				return
+			}
+			continue
+		}
+		if !isLetter(nextCh) {
+			return
+		}
+		ch := s.next() // consume the dot
+		for isAlphaNumeric(ch) {
+			if isDigit(ch) {
+				s.digits = append(s.digits, s.cursor-offset)
+			}
+			ch = s.nextBy(utf8.RuneLen(ch))
+		}
+	}
+}
+
+// isDelimiterAhead checks if the custom delimiter set by a DELIMITER directive starts at the cursor
+func (s *Lexer) isDelimiterAhead() bool {
+	return s.delimiter != "" && strings.HasPrefix(s.src[s.cursor:], s.delimiter)
+}
+
+// isDelimiter checks if a value is the custom delimiter set by a DELIMITER directive
+func (s *Lexer) isDelimiter(value string) bool {
+	return s.delimiter != "" && value == s.delimiter
+}
+
+// isClientDirectiveAhead checks if the line starting at the cursor is a client-side directive of the dialect
+func (s *Lexer) isClientDirectiveAhead() bool {
+	if !s.isLineStart() {
+		return false
+	}
+	line := s.src[s.cursor : s.cursor+lineLength(s.src[s.cursor:])]
+	switch {
+	case isMySQLFamily(s.config.DBMS):
+		return isDelimiterDirective(line)
+	case s.config.DBMS == DBMSSQLServer:
+		return isGoDirective(line)
+	case s.config.DBMS == DBMSOracle:
+		return strings.TrimSpace(line) == "/" || isSQLPlusCommand(line)
+	case s.config.DBMS == DBMSPostgres:
+		return len(line) > 1 && line[0] == '\\' && isAsciiLetter(rune(line[1]))
+	}
+	return false
+}
+
+// isLineStart checks if only spaces precede the cursor on its line
+func (s *Lexer) isLineStart() bool {
+	for pos := s.cursor - 1; pos >= 0; pos-- {
+		switch s.src[pos] {
+		case '\n', '\r':
+			return true
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// scanClientDirective scans a client-side directive up to the end of its line
+func (s *Lexer) scanClientDirective() *Token {
+	s.start = s.cursor
+	s.nextBy(lineLength(s.src[s.cursor:]))
+	if line := s.src[s.start:s.cursor]; isMySQLFamily(s.config.DBMS) {
+		s.delimiter = directiveArgument(line)
+		if s.delimiter == ";" {
+			s.delimiter = ""
+		}
+	}
+	return s.emit(CLIENT_DIRECTIVE)
 }
 
 func (s *Lexer) scanWhitespace() *Token {
@@ -455,6 +824,11 @@ func (s *Lexer) scanOperator(lastCh rune) *Token {
 	switch lastCh {
 	case '-':
 		if ch == '>' {
+			if s.config.DBMS == DBMSTrino {
+				// Trino uses -> as the lambda operator, e.g. transform(arr, x -> x * 2)
+				s.next()
+				return s.emit(OPERATOR)
+			}
 			ch = s.next()
 			if ch == '>' {
 				s.next()
@@ -531,6 +905,43 @@ func (s *Lexer) scanMultiLineComment() *Token {
 	return s.emit(MULTILINE_COMMENT)
 }
 
+// isFeatureCommentAhead checks if the multiline comment at the cursor is executed as SQL,
+// e.g. /*M! ... */ in MariaDB or /*T! ... */ in TiDB
+func (s *Lexer) isFeatureCommentAhead() bool {
+	switch s.config.DBMS {
+	case DBMSMariaDB:
+		return s.lookAhead(2) == '!' || (s.lookAhead(2) == 'M' && s.lookAhead(3) == '!')
+	case DBMSTiDB:
+		return s.lookAhead(2) == '!' || (s.lookAhead(2) == 'T' && s.lookAhead(3) == '!')
+	}
+	return false
+}
+
+// scanFeatureCommentStart scans the opening marker of an executable comment, including
+// the optional version or feature id, e.g. /*M!100301 or /*T![clustered_index]
+func (s *Lexer) scanFeatureCommentStart() *Token {
+	s.start = s.cursor
+	ch := s.nextBy(2) // consume the opening slash and asterisk
+	if ch != '!' {
+		ch = s.next() // consume the M or T
+	}
+	ch = s.next() // consume the exclamation mark
+	if ch == '[' {
+		for !isEOF(ch) && ch != ']' {
+			ch = s.next()
+		}
+		if ch == ']' {
+			s.next()
+		}
+	} else {
+		for isDigit(ch) {
+			ch = s.next()
+		}
+	}
+	s.inFeatureComment = true
+	return s.emit(FEATURE_COMMENT)
+}
+
 func (s *Lexer) scanPunctuation() *Token {
 	s.start = s.cursor
 	s.next()
@@ -561,6 +972,61 @@ func (s *Lexer) scanDollarQuotedString() *Token {
 	return s.emit(ERROR)
 }
 
+func (s *Lexer) scanIonLiteral() *Token {
+	s.start = s.cursor
+	ch := s.next() // consume the opening backtick
+	for ch != '`' {
+		if isEOF(ch) {
+			return s.emit(INCOMPLETE_STRING)
+		}
+		ch = s.next()
+	}
+	s.next() // consume the closing backtick
+	return s.emit(STRING)
+}
+
+func (s *Lexer) scanCollectionLiteral() *Token {
+	s.start = s.cursor
+	depth := 0
+	for ch := s.peek(); !isEOF(ch); ch = s.peek() {
+		switch {
+		case ch == '{' || ch == '[' || (ch == '<' && s.lookAhead(1) == '<'):
+			depth++
+		case ch == '}' || ch == ']' || (ch == '>' && s.lookAhead(1) == '>'):
+			depth--
+		case ch == '\'' || ch == '`':
+			// skip over nested string and Ion literals so their content cannot close the collection
+			s.skipQuoted(ch)
+			continue
+		}
+		if ch == '<' || ch == '>' {
+			s.next() // consume the first character of << or >>
+		}
+		s.next()
+		if depth == 0 {
+			return s.emit(COLLECTION_LITERAL)
+		}
+	}
+	return s.emit(ERROR)
+}
+
+// skipQuoted advances the cursor past the quoted literal starting at the cursor
+func (s *Lexer) skipQuoted(quote rune) {
+	ch := s.next() // consume the opening quote
+	for !isEOF(ch) {
+		if ch == quote {
+			if s.lookAhead(1) == quote {
+				// escaped quote, e.g. 'it''s'
+				ch = s.nextBy(2)
+				continue
+			}
+			s.next() // consume the closing quote
+			return
+		}
+		ch = s.next()
+	}
+}
+
 func (s *Lexer) scanPositionalParameter() *Token {
 	s.start = s.cursor
 	ch := s.nextBy(2) // consume the dollar sign and the number
@@ -585,6 +1051,134 @@ func (s *Lexer) scanBindParameter() *Token {
 	return s.emit(BIND_PARAMETER)
 }
 
+// isObjectSuffixStart checks if the rune starts a suffix attached to the identifier being scanned,
+// i.e. an index hint in CockroachDB or a database link in Oracle
+func (s *Lexer) isObjectSuffixStart(ch rune) bool {
+	if ch != '@' {
+		return false
+	}
+	// SYSDATE@! in Oracle is not a database link
+	return s.config.DBMS == DBMSCockroachDB || (s.config.DBMS == DBMSOracle && isLetter(s.lookAhead(1)))
+}
+
+// isIndexHintStart checks if the rune following an @ starts a CockroachDB index hint,
+// i.e. an index name or a braced hint such as {FORCE_INDEX=idx}
+func isIndexHintStart(ch rune) bool {
+	return isLetter(ch) || ch == '{'
+}
+
+func (s *Lexer) scanIndexHint() *Token {
+	s.start = s.cursor
+	ch := s.next() // consume the @
+	if ch == '{' {
+		// e.g. users@{FORCE_INDEX=users_email_idx}
+		for ch != '}' {
+			if isEOF(ch) {
+				return s.emit(ERROR)
+			}
+			ch = s.next()
+		}
+		s.next() // consume the closing brace
+		return s.emit(INDEX_HINT)
+	}
+	for isAlphaNumeric(ch) {
+		ch = s.nextBy(utf8.RuneLen(ch))
+	}
+	return s.emit(INDEX_HINT)
+}
+
+// isVariantPathAhead checks if the colon at the cursor starts a semi-structured path
+// attached to the preceding expression, e.g. v:customer.name, but not a cast (::) or bind parameter
+func (s *Lexer) isVariantPathAhead() bool {
+	prevCh := s.lookAhead(-1)
+	if !isAlphaNumeric(prevCh) && prevCh != '"' && prevCh != ']' && prevCh != ')' {
+		return false
+	}
+	nextCh := s.lookAhead(1)
+	return isLetter(nextCh) || nextCh == '"'
+}
+
+// scanVariantPath scans a semi-structured path, e.g. :customer.name, :items[0].price or :"key with space"
+func (s *Lexer) scanVariantPath() *Token {
+	s.start = s.cursor
+	ch := s.next() // consume the colon
+	for {
+		switch {
+		case ch == '"' || ch == '[':
+			closing := '"'
+			if ch == '[' {
+				closing = ']'
+			}
+			for ch = s.next(); ch != closing; ch = s.next() {
+				if isEOF(ch) {
+					return s.emit(ERROR)
+				}
+			}
+			ch = s.next() // consume the closing quote or bracket
+		case ch == '.' && (isLetter(s.lookAhead(1)) || s.lookAhead(1) == '"'):
+			ch = s.next()
+		case isAlphaNumeric(ch):
+			ch = s.nextBy(utf8.RuneLen(ch))
+		default:
+			return s.emit(VARIANT_PATH)
+		}
+	}
+}
+
+// scanStage scans a stage reference, e.g. @my_stage/path/, @~ or @%orders
+func (s *Lexer) scanStage() *Token {
+	s.start = s.cursor
+	ch := s.next() // consume the @
+	for !isEOF(ch) && !isSpace(ch) && ch != ';' && ch != ',' && ch != '(' && ch != ')' {
+		if ch == '"' {
+			for ch = s.next(); ch != '"'; ch = s.next() {
+				if isEOF(ch) {
+					return s.emit(ERROR)
+				}
+			}
+		}
+		ch = s.nextBy(utf8.RuneLen(ch))
+	}
+	return s.emit(STAGE)
+}
+
+// isLocalFilePathAhead checks if a local file path starts at the cursor, e.g. file:///tmp/data.csv
+func (s *Lexer) isLocalFilePathAhead() bool {
+	return len(s.src)-s.cursor > len("file://") && strings.EqualFold(s.src[s.cursor:s.cursor+len("file://")], "file://")
+}
+
+func (s *Lexer) scanLocalFilePath() *Token {
+	s.start = s.cursor
+	ch := s.nextBy(len("file://"))
+	for !isEOF(ch) && !isSpace(ch) && ch != ';' && ch != ',' && ch != ')' {
+		ch = s.nextBy(utf8.RuneLen(ch))
+	}
+	return s.emit(FILE_PATH)
+}
+
+// scanDBLink scans a database link, e.g. @remote_db or @sales.example.com
+func (s *Lexer) scanDBLink() *Token {
+	s.start = s.cursor
+	ch := s.next() // consume the @
+	for isAlphaNumeric(ch) || ch == '.' || ch == '$' || ch == '#' {
+		ch = s.nextBy(utf8.RuneLen(ch))
+	}
+	return s.emit(DB_LINK)
+}
+
+func (s *Lexer) scanSubstitutionVariable() *Token {
+	s.start = s.cursor
+	ch := s.nextBy(2) // consume ${
+	for ch != '}' {
+		if isEOF(ch) {
+			return s.emit(ERROR)
+		}
+		ch = s.next()
+	}
+	s.next() // consume the closing brace
+	return s.emit(SUBSTITUTION_VARIABLE)
+}
+
 func (s *Lexer) scanSystemVariable() *Token {
 	s.start = s.cursor
 	ch := s.nextBy(2) // consume @@
@@ -630,6 +1224,16 @@ func (s *Lexer) emit(t TokenType) *Token {
 		tok.quotes = nil
 	}
 
+	if s.config.DBMS == DBMSDuckDB && isValueToken(tok) {
+		s.duckdb.update(tok)
+	}
+	if s.config.DBMS == DBMSSnowflake && isValueToken(tok) {
+		s.snowflake.update(tok)
+	}
+	if s.embeddedSQL.enabled && isValueToken(tok) {
+		s.embeddedSQL.update(tok)
+	}
+
 	// Reset lexer state
 	s.start = s.cursor
 	s.digits = nil
@@ -977,6 +977,631 @@ here */`,
 				{IDENT, "c"},
 			},
 		},
+		{
+			name:  "Trino lambda expression",
+			input: "transform(arr, x -> x * 2)",
+			expected: []TokenSpec{
+				{FUNCTION, "transform"},
+				{PUNCTUATION, "("},
+				{IDENT, "arr"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{IDENT, "x"},
+				{SPACE, " "},
+				{OPERATOR, "->"},
+				{SPACE, " "},
+				{IDENT, "x"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{NUMBER, "2"},
+				{PUNCTUATION, ")"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTrino)},
+		},
+		{
+			name:  "Trino piecewise quoted qualified name",
+			input: `SELECT * FROM hive."web".events, "iceberg".sales."orders"`,
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{QUOTED_IDENT, `hive."web".events`},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{QUOTED_IDENT, `"iceberg".sales."orders"`},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTrino)},
+		},
+		{
+			name:  "PostgreSQL piecewise quoted qualified name",
+			input: `SELECT * FROM "Sales".orders, sales."Orders"`,
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{QUOTED_IDENT, `"Sales".orders`},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{QUOTED_IDENT, `sales."Orders"`},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:  "SQL Server piecewise bracketed qualified name",
+			input: `SELECT * FROM [my.db].dbo.[Orders]`,
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{QUOTED_IDENT, `[my.db].dbo.[Orders]`},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name:  "MySQL piecewise quoted qualified name",
+			input: "SELECT * FROM shop.`order items`",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{QUOTED_IDENT, "shop.`order items`"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:  "Spark SQL substitution variables",
+			input: "SELECT * FROM t WHERE dt = ${hivevar:run_date} AND id = ${id}",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "dt"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{SUBSTITUTION_VARIABLE, "${hivevar:run_date}"},
+				{SPACE, " "},
+				{KEYWORD, "AND"},
+				{SPACE, " "},
+				{IDENT, "id"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{SUBSTITUTION_VARIABLE, "${id}"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSparkSQL)},
+		},
+		{
+			name:  "Spark SQL path-based table",
+			input: "SELECT * FROM delta.`/mnt/events`, db.`my table`",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{FILE_PATH, "delta.`/mnt/events`"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{QUOTED_IDENT, "db.`my table`"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSparkSQL)},
+		},
+		{
+			name:  "Databricks named parameter marker",
+			input: "SELECT raw:owner FROM t WHERE id = :id",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{IDENT, "raw"},
+				{OPERATOR, ":"},
+				{IDENT, "owner"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "id"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{BIND_PARAMETER, ":id"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSparkSQL)},
+		},
+		{
+			name:  "CockroachDB index hint",
+			input: "SELECT * FROM users@users_email_idx, orders@{FORCE_INDEX=primary}",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "users"},
+				{INDEX_HINT, "@users_email_idx"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{IDENT, "orders"},
+				{INDEX_HINT, "@{FORCE_INDEX=primary}"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSCockroachDB)},
+		},
+		{
+			name:  "CockroachDB JSONB containment is not an index hint",
+			input: "SELECT * FROM users WHERE data@>'{}'",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "users"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "data"},
+				{JSON_OP, "@>"},
+				{STRING, "'{}'"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSCockroachDB)},
+		},
+		{
+			name:  "CockroachDB UPSERT and IMPORT commands",
+			input: "UPSERT INTO users; IMPORT INTO users",
+			expected: []TokenSpec{
+				{COMMAND, "UPSERT"},
+				{SPACE, " "},
+				{KEYWORD, "INTO"},
+				{SPACE, " "},
+				{IDENT, "users"},
+				{PUNCTUATION, ";"},
+				{SPACE, " "},
+				{COMMAND, "IMPORT"},
+				{SPACE, " "},
+				{KEYWORD, "INTO"},
+				{SPACE, " "},
+				{IDENT, "users"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSCockroachDB)},
+		},
+		{
+			name:  "UPSERT outside CockroachDB",
+			input: "SELECT upsert FROM t",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{IDENT, "upsert"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "t"},
+			},
+		},
+		{
+			name:  "OVERWRITE outside Spark SQL",
+			input: "UPDATE t SET overwrite = 1",
+			expected: []TokenSpec{
+				{COMMAND, "UPDATE"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{KEYWORD, "SET"},
+				{SPACE, " "},
+				{IDENT, "overwrite"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{NUMBER, "1"},
+			},
+		},
+		{
+			name:  "DuckDB file path and numeric separators",
+			input: "FROM 'data/*.parquet' SELECT * WHERE amount > 1_000_000",
+			expected: []TokenSpec{
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{FILE_PATH, "'data/*.parquet'"},
+				{SPACE, " "},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "amount"},
+				{SPACE, " "},
+				{OPERATOR, ">"},
+				{SPACE, " "},
+				{NUMBER, "1_000_000"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSDuckDB)},
+		},
+		{
+			name:  "DuckDB COPY command",
+			input: "COPY t TO 'out.parquet'",
+			expected: []TokenSpec{
+				{COMMAND, "COPY"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{IDENT, "TO"},
+				{SPACE, " "},
+				{FILE_PATH, "'out.parquet'"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSDuckDB)},
+		},
+		{
+			name:  "DuckDB star modifiers",
+			input: "SELECT * REPLACE (a + 1 AS a) FROM read_csv_auto('t.csv')",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{WILDCARD, "*"},
+				{SPACE, " "},
+				{KEYWORD, "REPLACE"},
+				{SPACE, " "},
+				{PUNCTUATION, "("},
+				{IDENT, "a"},
+				{SPACE, " "},
+				{OPERATOR, "+"},
+				{SPACE, " "},
+				{NUMBER, "1"},
+				{SPACE, " "},
+				{KEYWORD, "AS"},
+				{SPACE, " "},
+				{IDENT, "a"},
+				{PUNCTUATION, ")"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{FUNCTION, "read_csv_auto"},
+				{PUNCTUATION, "("},
+				{FILE_PATH, "'t.csv'"},
+				{PUNCTUATION, ")"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSDuckDB)},
+		},
+		{
+			name:  "Cassandra uuid and collection literals",
+			input: "UPDATE t SET m = {'k': 1} WHERE id = 123e4567-e89b-12d3-a456-426614174000 // note",
+			expected: []TokenSpec{
+				{COMMAND, "UPDATE"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{KEYWORD, "SET"},
+				{SPACE, " "},
+				{IDENT, "m"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{PUNCTUATION, "{"},
+				{STRING, "'k'"},
+				{OPERATOR, ":"},
+				{SPACE, " "},
+				{NUMBER, "1"},
+				{PUNCTUATION, "}"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "id"},
+				{SPACE, " "},
+				{OPERATOR, "="},
+				{SPACE, " "},
+				{UUID, "123e4567-e89b-12d3-a456-426614174000"},
+				{SPACE, " "},
+				{COMMENT, "// note"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSCassandra)},
+		},
+		{
+			name:  "PartiQL tuple, bag and Ion literals",
+			input: "INSERT INTO \"T\" VALUE {'pk': 'a}b', 'tags': <<1, 2>>, 'doc': `{x: 1}`}",
+			expected: []TokenSpec{
+				{COMMAND, "INSERT"},
+				{SPACE, " "},
+				{KEYWORD, "INTO"},
+				{SPACE, " "},
+				{QUOTED_IDENT, `"T"`},
+				{SPACE, " "},
+				{IDENT, "VALUE"},
+				{SPACE, " "},
+				{COLLECTION_LITERAL, "{'pk': 'a}b', 'tags': <<1, 2>>, 'doc': `{x: 1}`}"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPartiQL)},
+		},
+		{
+			name:  "PartiQL Ion literal",
+			input: "SELECT `{a: 1}`",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{STRING, "`{a: 1}`"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPartiQL)},
+		},
+		{
+			name:  "lone at sign operator",
+			input: "SELECT @ -5",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{OPERATOR, "@"},
+				{SPACE, " "},
+				{NUMBER, "-5"},
+			},
+		},
+		{
+			name:  "PostgreSQL at sign operators",
+			input: "SELECT @ -5 WHERE x @ '{1}'",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{OPERATOR, "@"},
+				{SPACE, " "},
+				{NUMBER, "-5"},
+				{SPACE, " "},
+				{KEYWORD, "WHERE"},
+				{SPACE, " "},
+				{IDENT, "x"},
+				{SPACE, " "},
+				{OPERATOR, "@"},
+				{SPACE, " "},
+				{STRING, "'{1}'"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:  "Teradata abbreviated commands",
+			input: "SEL sel_col FROM t",
+			expected: []TokenSpec{
+				{COMMAND, "SEL"},
+				{SPACE, " "},
+				{IDENT, "sel_col"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "t"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTeradata)},
+		},
+		{
+			name:  "TiDB feature comment",
+			input: "id BIGINT /*T![clustered_index] CLUSTERED */",
+			expected: []TokenSpec{
+				{IDENT, "id"},
+				{SPACE, " "},
+				{IDENT, "BIGINT"},
+				{SPACE, " "},
+				{FEATURE_COMMENT, "/*T![clustered_index]"},
+				{SPACE, " "},
+				{IDENT, "CLUSTERED"},
+				{SPACE, " "},
+				{FEATURE_COMMENT, "*/"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTiDB)},
+		},
+		{
+			name:  "MariaDB executable comment",
+			input: "/*M!100301 SET a=1 */ /*T! b */",
+			expected: []TokenSpec{
+				{FEATURE_COMMENT, "/*M!100301"},
+				{SPACE, " "},
+				{KEYWORD, "SET"},
+				{SPACE, " "},
+				{IDENT, "a"},
+				{OPERATOR, "="},
+				{NUMBER, "1"},
+				{SPACE, " "},
+				{FEATURE_COMMENT, "*/"},
+				{SPACE, " "},
+				{MULTILINE_COMMENT, "/*T! b */"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMariaDB)},
+		},
+		{
+			name:  "Snowflake variant path",
+			input: "SELECT v:items[0].price::number, $1:\"a b\" FROM raw",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{IDENT, "v"},
+				{VARIANT_PATH, ":items[0].price"},
+				{OPERATOR, "::"},
+				{IDENT, "number"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{POSITIONAL_PARAMETER, "$1"},
+				{VARIANT_PATH, ":\"a b\""},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "raw"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSnowflake)},
+		},
+		{
+			name:  "Snowflake stages and local files",
+			input: "PUT file:///tmp/a.csv @~/staged; COPY INTO t FROM @db.s.st/path/",
+			expected: []TokenSpec{
+				{IDENT, "PUT"},
+				{SPACE, " "},
+				{FILE_PATH, "file:///tmp/a.csv"},
+				{SPACE, " "},
+				{STAGE, "@~/staged"},
+				{PUNCTUATION, ";"},
+				{SPACE, " "},
+				{KEYWORD, "COPY"},
+				{SPACE, " "},
+				{KEYWORD, "INTO"},
+				{SPACE, " "},
+				{IDENT, "t"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{STAGE, "@db.s.st/path/"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSnowflake)},
+		},
+		{
+			name:  "Snowflake IDENTIFIER",
+			input: "IDENTIFIER('db.t'), IDENTIFIER($name)",
+			expected: []TokenSpec{
+				{FUNCTION, "IDENTIFIER"},
+				{PUNCTUATION, "("},
+				{QUOTED_IDENT, "'db.t'"},
+				{PUNCTUATION, ")"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{FUNCTION, "IDENTIFIER"},
+				{PUNCTUATION, "("},
+				{BIND_PARAMETER, "$name"},
+				{PUNCTUATION, ")"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSnowflake)},
+		},
+		{
+			name:  "Oracle database link",
+			input: "FROM orders@remote_db, SYSDATE@!",
+			expected: []TokenSpec{
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "orders"},
+				{DB_LINK, "@remote_db"},
+				{PUNCTUATION, ","},
+				{SPACE, " "},
+				{IDENT, "SYSDATE@!"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
+		},
+		{
+			name:  "MySQL DELIMITER directive in script mode",
+			input: "DELIMITER $$\nBEGIN SELECT 1; END$$\nDELIMITER ;\nSELECT 1;",
+			expected: []TokenSpec{
+				{CLIENT_DIRECTIVE, "DELIMITER $$"},
+				{SPACE, "\n"},
+				{COMMAND, "BEGIN"},
+				{SPACE, " "},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{NUMBER, "1"},
+				{PUNCTUATION, ";"},
+				{SPACE, " "},
+				{KEYWORD, "END"},
+				{PUNCTUATION, "$$"},
+				{SPACE, "\n"},
+				{CLIENT_DIRECTIVE, "DELIMITER ;"},
+				{SPACE, "\n"},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{NUMBER, "1"},
+				{PUNCTUATION, ";"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL), WithScriptMode(true)},
+		},
+		{
+			name:  "MySQL DELIMITER without script mode",
+			input: "DELIMITER //",
+			expected: []TokenSpec{
+				{IDENT, "DELIMITER"},
+				{SPACE, " "},
+				{OPERATOR, "//"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:  "sqlcmd GO directive",
+			input: "SELECT 1\nGO 5\n  go -- next batch\nSELECT go FROM t",
+			expected: []TokenSpec{
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{NUMBER, "1"},
+				{SPACE, "\n"},
+				{CLIENT_DIRECTIVE, "GO 5"},
+				{SPACE, "\n  "},
+				{CLIENT_DIRECTIVE, "go -- next batch"},
+				{SPACE, "\n"},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{IDENT, "go"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "t"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer), WithScriptMode(true)},
+		},
+		{
+			name:  "SQL*Plus slash and SET ECHO ON",
+			input: "SET ECHO ON\nBEGIN NULL; END;\n/\nSELECT 4\n/ 2 FROM dual",
+			expected: []TokenSpec{
+				{CLIENT_DIRECTIVE, "SET ECHO ON"},
+				{SPACE, "\n"},
+				{COMMAND, "BEGIN"},
+				{SPACE, " "},
+				{NULL, "NULL"},
+				{PUNCTUATION, ";"},
+				{SPACE, " "},
+				{KEYWORD, "END"},
+				{PUNCTUATION, ";"},
+				{SPACE, "\n"},
+				{CLIENT_DIRECTIVE, "/"},
+				{SPACE, "\n"},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{NUMBER, "4"},
+				{SPACE, "\n"},
+				{OPERATOR, "/"},
+				{SPACE, " "},
+				{NUMBER, "2"},
+				{SPACE, " "},
+				{KEYWORD, "FROM"},
+				{SPACE, " "},
+				{IDENT, "dual"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSOracle), WithScriptMode(true)},
+		},
+		{
+			name:  "psql meta-commands",
+			input: "\\connect analytics\n\\set id 42\nSELECT :id",
+			expected: []TokenSpec{
+				{CLIENT_DIRECTIVE, "\\connect analytics"},
+				{SPACE, "\n"},
+				{CLIENT_DIRECTIVE, "\\set id 42"},
+				{SPACE, "\n"},
+				{COMMAND, "SELECT"},
+				{SPACE, " "},
+				{OPERATOR, ":"},
+				{IDENT, "id"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres), WithScriptMode(true)},
+		},
 	}
 
 	for _, tt := range tests {
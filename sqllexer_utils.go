@@ -21,12 +21,36 @@ const (
 	DBMSOracle DBMSType = "oracle"
 	// DBMSSnowflake is a Snowflake Server
 	DBMSSnowflake DBMSType = "snowflake"
+	// DBMSTrino is a Trino Server
+	DBMSTrino       DBMSType = "trino"
+	DBMSTrinoAlias1 DBMSType = "presto"
+	// DBMSSparkSQL is a Spark SQL Server
+	DBMSSparkSQL       DBMSType = "sparksql"
+	DBMSSparkSQLAlias1 DBMSType = "hive"
+	DBMSSparkSQLAlias2 DBMSType = "databricks"
+	// DBMSCockroachDB is a CockroachDB Server
+	DBMSCockroachDB DBMSType = "cockroachdb"
+	// DBMSDuckDB is a DuckDB database
+	DBMSDuckDB DBMSType = "duckdb"
+	// DBMSCassandra is a Cassandra Server
+	DBMSCassandra DBMSType = "cassandra"
+	// DBMSPartiQL is a PartiQL compatible database, e.g. Amazon DynamoDB or QLDB
+	DBMSPartiQL DBMSType = "partiql"
+	// DBMSTeradata is a Teradata Server
+	DBMSTeradata DBMSType = "teradata"
+	// DBMSMariaDB is a MariaDB Server
+	DBMSMariaDB DBMSType = "mariadb"
+	// DBMSTiDB is a TiDB Server
+	DBMSTiDB DBMSType = "tidb"
 )
 
 var dbmsAliases = map[DBMSType]DBMSType{
 	DBMSSQLServerAlias1: DBMSSQLServer,
 	DBMSSQLServerAlias2: DBMSSQLServer,
 	DBMSPostgresAlias1:  DBMSPostgres,
+	DBMSTrinoAlias1:     DBMSTrino,
+	DBMSSparkSQLAlias1:  DBMSSparkSQL,
+	DBMSSparkSQLAlias2:  DBMSSparkSQL,
 }
 
 func getDBMSFromAlias(alias DBMSType) DBMSType {
@@ -59,11 +83,216 @@ var commands = []string{
 	"CLONE",
 }
 
+// sqlPlusSystemVariables are the SQL*Plus settings changed by SET commands, e.g. SET ECHO ON
+var sqlPlusSystemVariables = map[string]struct{}{
+	"ECHO":          {},
+	"FEEDBACK":      {},
+	"SERVEROUTPUT":  {},
+	"DEFINE":        {},
+	"VERIFY":        {},
+	"HEADING":       {},
+	"PAGESIZE":      {},
+	"LINESIZE":      {},
+	"TIMING":        {},
+	"TERMOUT":       {},
+	"TRIMSPOOL":     {},
+	"SQLBLANKLINES": {},
+}
+
+// teradataCommandAbbreviations maps Teradata abbreviated commands to the commands they stand for
+var teradataCommandAbbreviations = map[string]string{
+	"SEL": "SELECT",
+	"INS": "INSERT",
+	"UPD": "UPDATE",
+	"DEL": "DELETE",
+	"CT":  "CREATE",
+}
+
+// dialectKeyword is a keyword recognized in a single dialect only
+type dialectKeyword struct {
+	word             string
+	tokenType        TokenType
+	isTableIndicator bool
+}
+
+// dialectKeywords maps a dialect to the keywords only it recognizes, e.g. UPSERT in CockroachDB
+var dialectKeywords = map[DBMSType][]dialectKeyword{
+	DBMSCockroachDB: {
+		{word: "UPSERT", tokenType: COMMAND, isTableIndicator: true},
+		{word: "IMPORT", tokenType: COMMAND},
+	},
+	DBMSSparkSQL: {
+		{word: "OVERWRITE", tokenType: KEYWORD, isTableIndicator: true}, // INSERT OVERWRITE TABLE t
+	},
+	DBMSDuckDB: {
+		{word: "COPY", tokenType: COMMAND, isTableIndicator: true}, // COPY t TO 'file.parquet'
+	},
+}
+
 var tableIndicatorCommands = []string{
 	"JOIN",
 	"UPDATE",
 	"STRAIGHT_JOIN", // MySQL
 	"CLONE",         // Snowflake
+	"MERGE",         // MERGE t USING s in SQL Server
+}
+
+// tableListEnds are the keywords ending a list of table references, e.g. WHERE in FROM a, b WHERE ...
+var tableListEnds = map[string]struct{}{
+	"WHERE":     {},
+	"GROUP":     {},
+	"ORDER":     {},
+	"HAVING":    {},
+	"LIMIT":     {},
+	"OFFSET":    {},
+	"UNION":     {},
+	"EXCEPT":    {},
+	"INTERSECT": {},
+	"MINUS":     {},
+	"WINDOW":    {},
+	"QUALIFY":   {},
+	"RETURNING": {},
+	"SET":       {},
+	"VALUES":    {},
+	"WHEN":      {},
+	"FOR":       {},
+	"INTO":      {},
+	"VIEW":      {}, // LATERAL VIEW explode(m) t AS k, v in Spark SQL
+}
+
+// columnClauseKeywords map the keywords starting a clause to the clause they start,
+// or to an empty clause when the identifiers of the clause are not columns, e.g. LIMIT or RETURNING
+var columnClauseKeywords = map[string]string{
+	"FROM":      "",
+	"INTO":      "",
+	"WHERE":     ClauseWhere,
+	"ON":        ClauseJoin,
+	"USING":     ClauseJoin,
+	"GROUP":     ClauseGroupBy,
+	"HAVING":    ClauseHaving,
+	"ORDER":     ClauseOrderBy,
+	"SET":       ClauseSet,
+	"VALUES":    "",
+	"LIMIT":     "",
+	"OFFSET":    "",
+	"RETURNING": "",
+	"WINDOW":    "",
+	"QUALIFY":   "",
+	"UNION":     "",
+	"EXCEPT":    "",
+	"INTERSECT": "",
+	"MINUS":     "",
+	"OPTION":    "", // query hints in SQL Server, e.g. OPTION (RECOMPILE)
+}
+
+// columnStopWords are the words the lexer reads as identifiers which are not columns, e.g. THEN or NULLS
+var columnStopWords = map[string]struct{}{
+	"WHEN":              {},
+	"THEN":              {},
+	"OVER":              {},
+	"PARTITION":         {},
+	"ROWS":              {},
+	"RANGE":             {},
+	"UNBOUNDED":         {},
+	"PRECEDING":         {},
+	"FOLLOWING":         {},
+	"CURRENT":           {},
+	"ROW":               {},
+	"NULLS":             {},
+	"FIRST":             {},
+	"LAST":              {},
+	"NEXT":              {},
+	"FETCH":             {},
+	"INTERVAL":          {},
+	"ESCAPE":            {},
+	"FILTER":            {},
+	"WITHIN":            {},
+	"COLLATE":           {},
+	"SIMILAR":           {},
+	"TO":                {},
+	"REGEXP":            {},
+	"RLIKE":             {},
+	"XOR":               {},
+	"DIV":               {},
+	"MOD":               {},
+	"FULL":              {},
+	"CROSS":             {},
+	"NATURAL":           {},
+	"LATERAL":           {},
+	"APPLY":             {},
+	"DO":                {},
+	"NOTHING":           {},
+	"CONFLICT":          {},
+	"DUPLICATE":         {},
+	"BOTH":              {},
+	"LEADING":           {},
+	"TRAILING":          {},
+	"CURRENT_DATE":      {},
+	"CURRENT_TIME":      {},
+	"CURRENT_TIMESTAMP": {},
+	"CURRENT_USER":      {},
+	"SESSION_USER":      {},
+	"LOCALTIME":         {},
+	"LOCALTIMESTAMP":    {},
+	"SYSDATE":           {},
+	"SYSTIMESTAMP":      {},
+}
+
+// literalPrefixes are the types and the prefixes the lexer reads as identifiers before a string literal,
+// e.g. DATE '2024-01-01' or N'text'
+var literalPrefixes = map[string]struct{}{
+	"DATE":        {},
+	"TIME":        {},
+	"TIMESTAMP":   {},
+	"TIMESTAMPTZ": {},
+	"N":           {},
+	"E":           {},
+	"B":           {},
+	"X":           {},
+}
+
+// comparisonOperators are the operators comparing the two operands of a predicate
+var comparisonOperators = map[string]struct{}{
+	"=":   {},
+	"==":  {},
+	"<>":  {},
+	"!=":  {},
+	"<":   {},
+	"<=":  {},
+	">":   {},
+	">=":  {},
+	"<=>": {},
+}
+
+// predicateKeywords are the keywords comparing the two operands of a predicate, e.g. IN or LIKE
+var predicateKeywords = map[string]struct{}{
+	"IN":      {},
+	"LIKE":    {},
+	"ILIKE":   {},
+	"RLIKE":   {},
+	"REGEXP":  {},
+	"BETWEEN": {},
+}
+
+func isComparisonOperator(value string) bool {
+	_, ok := comparisonOperators[value]
+	return ok
+}
+
+func isPredicateKeyword(value string) bool {
+	_, ok := lookupKeyword(predicateKeywords, value)
+	return ok
+}
+
+// isSetOperator checks if a word combines the results of two queries, e.g. UNION or EXCEPT
+func isSetOperator(word string) bool {
+	return strings.EqualFold(word, "UNION") || strings.EqualFold(word, "INTERSECT") ||
+		strings.EqualFold(word, "EXCEPT") || strings.EqualFold(word, "MINUS")
+}
+
+func isLiteralPrefix(value string) bool {
+	_, ok := lookupKeyword(literalPrefixes, value)
+	return ok
 }
 
 var tableIndicatorKeywords = []string{
@@ -154,6 +383,38 @@ var keywords = []string{
 	"ONLY",
 }
 
+// sparkFileFormats are the data sources Spark SQL accepts as the prefix of a path-based table
+var sparkFileFormats = map[string]struct{}{
+	"DELTA":      {},
+	"PARQUET":    {},
+	"CSV":        {},
+	"JSON":       {},
+	"ORC":        {},
+	"AVRO":       {},
+	"TEXT":       {},
+	"BINARYFILE": {},
+}
+
+// duckDBFileReaders are the DuckDB table functions whose first argument is a file path
+var duckDBFileReaders = map[string]struct{}{
+	"READ_CSV":          {},
+	"READ_CSV_AUTO":     {},
+	"READ_PARQUET":      {},
+	"PARQUET_SCAN":      {},
+	"READ_JSON":         {},
+	"READ_JSON_AUTO":    {},
+	"READ_NDJSON":       {},
+	"READ_NDJSON_AUTO":  {},
+	"READ_TEXT":         {},
+	"READ_BLOB":         {},
+	"DELTA_SCAN":        {},
+	"ICEBERG_SCAN":      {},
+	"SNIFF_CSV":         {},
+	"PARQUET_METADATA":  {},
+	"PARQUET_SCHEMA":    {},
+	"READ_JSON_OBJECTS": {},
+}
+
 var (
 	// Pre-defined constants for common values
 	booleanValues = []string{
@@ -268,11 +529,145 @@ func trimQuotes(token *Token) string {
 	return trimmedToken.String()
 }
 
+// foldIdentifierCase folds the letters outside of the quotes at the given indexes the way the dialect folds
+// unquoted identifiers, e.g. "Sales".Orders to "Sales".orders in PostgreSQL. Only ASCII letters are folded,
+// as PostgreSQL does, so that the quote indexes still apply to the folded value.
+func foldIdentifierCase(value string, quotes []int, dbms DBMSType) string {
+	fold := identifierCaseFolding(dbms)
+	if fold == nil {
+		return value
+	}
+
+	folded := []byte(value)
+	inQuotes := false
+	next := 0 // index of the next quote in quotes
+	for i := range folded {
+		if next < len(quotes) && quotes[next] == i {
+			inQuotes = !inQuotes
+			next++
+			continue
+		}
+		if !inQuotes {
+			folded[i] = fold(folded[i])
+		}
+	}
+	return string(folded)
+}
+
+// identifierCaseFolding returns how the dialect folds the letters of unquoted identifiers,
+// or nil for dialects that do not fold them
+func identifierCaseFolding(dbms DBMSType) func(byte) byte {
+	switch dbms {
+	case DBMSPostgres, DBMSCockroachDB, DBMSTrino, DBMSSparkSQL, DBMSCassandra:
+		return toASCIILower
+	case DBMSOracle, DBMSSnowflake:
+		return toASCIIUpper
+	default:
+		// e.g. MySQL and SQL Server, where the case sensitivity depends on the server configuration
+		return nil
+	}
+}
+
+func toASCIILower(ch byte) byte {
+	if 'A' <= ch && ch <= 'Z' {
+		return ch + 'a' - 'A'
+	}
+	return ch
+}
+
+func toASCIIUpper(ch byte) byte {
+	if 'a' <= ch && ch <= 'z' {
+		return ch - ('a' - 'A')
+	}
+	return ch
+}
+
+// newTableRef splits a table name as written into its catalog, schema and name.
+// The server of a four-part name, e.g. srv.db.dbo.orders in SQL Server, is not reported.
+func newTableRef(value string, quotes []int) TableRef {
+	if len(value) > 1 && value[0] == '\'' {
+		// object name passed as a string, e.g. IDENTIFIER('db.t') in Snowflake
+		value, quotes = value[1:len(value)-1], nil
+	}
+	parts, quoted := splitQualifiedName(value, quotes)
+	if len(parts) > 3 {
+		parts, quoted = parts[len(parts)-3:], quoted[len(quoted)-3:]
+	}
+	ref := TableRef{Quoted: quoted}
+	switch len(parts) {
+	case 3:
+		ref.Catalog, ref.Schema, ref.Name = parts[0], parts[1], parts[2]
+	case 2:
+		ref.Schema, ref.Name = parts[0], parts[1]
+	case 1:
+		ref.Name = parts[0]
+	}
+	return ref
+}
+
+// splitQualifiedName splits a qualified name at the dots outside of the quotes at the given indexes,
+// e.g. "my.db".dbo.[Orders] into my.db, dbo and Orders, and reports whether each part is quoted.
+// Empty parts are dropped.
+func splitQualifiedName(value string, quotes []int) (parts []string, quoted []bool) {
+	var part strings.Builder
+	partQuoted, inQuotes := false, false
+	next := 0 // index of the next quote in quotes
+
+	flush := func() {
+		if part.Len() > 0 {
+			parts = append(parts, part.String())
+			quoted = append(quoted, partQuoted)
+		}
+		part.Reset()
+		partQuoted = false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if next < len(quotes) && quotes[next] == i {
+			inQuotes = !inQuotes
+			partQuoted = true
+			next++
+			continue
+		}
+		if value[i] == '.' && !inQuotes {
+			flush()
+			continue
+		}
+		part.WriteByte(value[i])
+	}
+	flush()
+	return parts, quoted
+}
+
+// key returns a key identifying the table reference, quoting the quoted parts
+func (r TableRef) key() string {
+	var key strings.Builder
+	parts := []string{r.Catalog, r.Schema, r.Name}[3-len(r.Quoted):]
+	for i, part := range parts {
+		if i > 0 {
+			key.WriteByte('.')
+		}
+		if r.Quoted[i] {
+			key.WriteByte('"')
+		}
+		key.WriteString(part)
+		if r.Quoted[i] {
+			key.WriteByte('"')
+		}
+	}
+	return key.String()
+}
+
 // isDigit checks if a rune is a digit (0-9)
 func isDigit(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
+// isHexDigit checks if a rune is a hexadecimal digit (0-9, a-f or A-F)
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
 // isLeadingDigit checks if a rune is + or -
 func isLeadingSign(ch rune) bool {
 	return ch == '+' || ch == '-'
@@ -353,8 +748,322 @@ func isIdentifier(ch rune) bool {
 	return ch == '.' || ch == '?' || ch == '$' || ch == '#' || ch == '/' || ch == '@' || ch == '!' || isLetter(ch) || isDigit(ch)
 }
 
+// isSparkFileFormat checks if an identifier is a Spark SQL data source format
+func isSparkFileFormat(ident string) bool {
+	_, ok := sparkFileFormats[strings.ToUpper(ident)]
+	return ok
+}
+
+// isParameterMarkerPosition checks if the rune before a colon allows the colon to start
+// a named parameter marker, e.g. = :param, rather than a field access or a cast, e.g. raw:owner or x::int
+func isParameterMarkerPosition(prevCh rune) bool {
+	return isEOF(prevCh) || isSpace(prevCh) || prevCh == '(' || prevCh == ',' || (isOperator(prevCh) && prevCh != ':')
+}
+
+// trimIndexHint returns the index referenced by an index hint token, e.g. @users_email_idx or @{FORCE_INDEX=users_email_idx}
+func trimIndexHint(hint string) string {
+	hint = strings.TrimPrefix(hint, "@")
+	return strings.TrimSuffix(strings.TrimPrefix(hint, "{"), "}")
+}
+
+// isTableListEnd checks if a keyword ends a list of table references
+func isTableListEnd(value string) bool {
+	_, ok := lookupKeyword(tableListEnds, value)
+	return ok
+}
+
+// maxKeywordLength is the length of the longest word looked up by lookupKeyword
+const maxKeywordLength = 32
+
+// lookupKeyword looks a word up in a map keyed by upper-cased keywords,
+// upper-casing the word on the stack so that looking up every token does not allocate
+func lookupKeyword[V any](keywords map[string]V, word string) (V, bool) {
+	var upper [maxKeywordLength]byte
+	if len(word) > len(upper) {
+		var zero V
+		return zero, false
+	}
+	for i := 0; i < len(word); i++ {
+		ch := word[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		upper[i] = ch
+	}
+	value, ok := keywords[string(upper[:len(word)])]
+	return value, ok
+}
+
+// isMySQLFamily checks if a DBMS follows the MySQL lexical rules, e.g. backtick quoted identifiers and # comments
+func isMySQLFamily(dbms DBMSType) bool {
+	return dbms == DBMSMySQL || dbms == DBMSMariaDB || dbms == DBMSTiDB
+}
+
+// lineLength returns the length of the first line of src, excluding the line break
+func lineLength(src string) int {
+	if n := strings.IndexAny(src, "\r\n"); n >= 0 {
+		return n
+	}
+	return len(src)
+}
+
+// directiveArgument returns the first argument of a client-side directive, e.g. $$ in DELIMITER $$
+func directiveArgument(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// isDelimiterDirective checks if a line changes the statement delimiter, e.g. DELIMITER $$ in MySQL
+func isDelimiterDirective(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) == 2 && strings.EqualFold(fields[0], "DELIMITER")
+}
+
+// isGoDirective checks if a line ends a batch in sqlcmd, i.e. GO optionally followed by a count and a comment
+func isGoDirective(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "GO") {
+		return false
+	}
+	rest := fields[1:]
+	if len(rest) > 0 && strings.Trim(rest[0], "0123456789") == "" {
+		rest = rest[1:]
+	}
+	return len(rest) == 0 || strings.HasPrefix(rest[0], "--")
+}
+
+// isSQLPlusCommand checks if a line is a SQL*Plus command rather than SQL, e.g. SET ECHO ON or PROMPT
+func isSQLPlusCommand(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "PROMPT", "SPOOL":
+		return true
+	case "SET":
+		if len(fields) > 1 {
+			_, ok := sqlPlusSystemVariables[strings.ToUpper(fields[1])]
+			return ok
+		}
+	}
+	return false
+}
+
+// appendHintToken appends a token to the text of a table or query hint, e.g. INDEX(ix_a) or MAXDOP 1.
+// The hint name is upper-cased so that hints can be matched regardless of how they are written.
+func appendHintToken(hint *strings.Builder, token *Token, lastValueToken *LastValueToken, isHintName bool) {
+	value := token.Value
+	if isHintName && (token.Type == IDENT || token.Type == KEYWORD || token.Type == FUNCTION) {
+		value = strings.ToUpper(value)
+	}
+	// the arguments of a hint are attached to it, e.g. INDEX(ix_a), but not to a later word, e.g. OPTIMIZE FOR (@p = 1)
+	afterFunction := value == "(" && ((lastValueToken != nil && lastValueToken.Type == FUNCTION) || !strings.Contains(hint.String(), " "))
+	if hint.Len() > 0 && !afterFunction && value != ")" && value != "," && !strings.HasSuffix(hint.String(), "(") {
+		hint.WriteByte(' ')
+	}
+	hint.WriteString(value)
+}
+
+// isEmbeddedSQLProcedure checks if a procedure takes a SQL statement as its first argument,
+// e.g. sp_executesql or sys.sp_executesql in SQL Server
+func isEmbeddedSQLProcedure(name string) bool {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.EqualFold(name, "sp_executesql")
+}
+
+// unquoteEmbeddedSQL returns the SQL statement held by a string literal, unescaping doubled quotes
+func unquoteEmbeddedSQL(literal string) string {
+	return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+}
+
+// quoteEmbeddedSQL returns a string literal holding a SQL statement, escaping quotes by doubling them
+func quoteEmbeddedSQL(sql string) string {
+	return "'" + strings.ReplaceAll(sql, "'", "''") + "'"
+}
+
+// sequenceStage is how much of a sequence reference was read, e.g. NEXT VALUE of NEXT VALUE FOR seq
+type sequenceStage int
+
+const (
+	sequenceNone          sequenceStage = iota
+	sequenceAfterNext                   // NEXT or PREVIOUS, followed by VALUE FOR seq
+	sequenceAfterValue                  // NEXT VALUE, followed by FOR seq
+	sequenceAfterFunction               // NEXTVAL, CURRVAL, LASTVAL or SETVAL, followed by (seq)
+	sequenceAfterDDL                    // CREATE, ALTER, DROP, REPLACE or TEMPORARY, possibly followed by SEQUENCE seq
+	sequenceNameNext                    // the next identifier names a sequence
+)
+
+// sequenceStart returns the stage of the sequence reference the token starts, if any
+func sequenceStart(token *Token) sequenceStage {
+	if token.Type != IDENT && token.Type != FUNCTION && token.Type != KEYWORD && token.Type != COMMAND {
+		return sequenceNone
+	}
+	// most tokens are told apart by their length alone, sparing a comparison
+	switch value := token.Value; len(value) {
+	case 4:
+		if strings.EqualFold(value, "NEXT") {
+			return sequenceAfterNext
+		} else if strings.EqualFold(value, "DROP") {
+			return sequenceAfterDDL
+		}
+	case 5:
+		if strings.EqualFold(value, "ALTER") {
+			return sequenceAfterDDL
+		}
+	case 6:
+		if strings.EqualFold(value, "SETVAL") {
+			return sequenceAfterFunction
+		} else if strings.EqualFold(value, "CREATE") {
+			return sequenceAfterDDL
+		}
+	case 7:
+		if strings.EqualFold(value, "NEXTVAL") || strings.EqualFold(value, "CURRVAL") || strings.EqualFold(value, "LASTVAL") {
+			return sequenceAfterFunction
+		} else if strings.EqualFold(value, "REPLACE") {
+			return sequenceAfterDDL
+		}
+	case 8:
+		if strings.EqualFold(value, "PREVIOUS") {
+			return sequenceAfterNext
+		}
+	case 9:
+		if strings.EqualFold(value, "TEMPORARY") {
+			return sequenceAfterDDL
+		}
+	}
+	return sequenceNone
+}
+
+// isSequencePseudoColumn checks if an identifier reads a sequence through a pseudo column, e.g. seq.NEXTVAL
+func isSequencePseudoColumn(ident string) bool {
+	i := strings.LastIndexByte(ident, '.')
+	return i > 0 && (strings.EqualFold(ident[i+1:], "NEXTVAL") || strings.EqualFold(ident[i+1:], "CURRVAL"))
+}
+
+// commandNames maps the upper-cased commands to themselves, so that canonicalCommand does not allocate
+var commandNames = func() map[string]string {
+	names := make(map[string]string, len(commands)+len(tableIndicatorCommands))
+	for _, list := range [][]string{commands, tableIndicatorCommands} {
+		for _, command := range list {
+			names[command] = command
+		}
+	}
+	for _, keywords := range dialectKeywords {
+		for _, keyword := range keywords {
+			if keyword.tokenType == COMMAND {
+				names[keyword.word] = keyword.word
+			}
+		}
+	}
+	return names
+}()
+
+// canonicalCommand returns the upper-cased command of a command token, e.g. SELECT for select or for SEL in Teradata
+func canonicalCommand(command string, dbms DBMSType) string {
+	if dbms == DBMSTeradata {
+		if canonical, ok := lookupKeyword(teradataCommandAbbreviations, command); ok {
+			return canonical
+		}
+	}
+	if name, ok := lookupKeyword(commandNames, command); ok {
+		return name
+	}
+	return strings.ToUpper(command)
+}
+
+// isDDLCommand checks if a command defines or drops objects rather than reading or writing rows
+func isDDLCommand(command string) bool {
+	switch command {
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "CLONE":
+		return true
+	}
+	return false
+}
+
+// tableAccessOperation returns the operation of a table access from the command accessing the table.
+// UPSERT is reported as INSERT, and tables written by other commands, e.g. SELECT ... INTO t
+// or COPY INTO t in Snowflake, as INSERT.
+func tableAccessOperation(command string, write bool) string {
+	switch {
+	case isDDLCommand(command):
+		return "DDL"
+	case command == "INSERT", command == "UPDATE", command == "DELETE", command == "MERGE":
+		return command
+	case write:
+		return "INSERT"
+	}
+	return "SELECT"
+}
+
+// isTeradataLockWord checks if a word is part of the Teradata lock hint syntax rather than an object name
+func isTeradataLockWord(word string) bool {
+	switch strings.ToUpper(word) {
+	case "ROW", "ACCESS", "READ", "WRITE", "EXCLUSIVE", "CHECKSUM", "LOAD", "SHARE", "NOWAIT", "OVERRIDE", "FOR", "MODE":
+		return true
+	}
+	return false
+}
+
 // isValueToken checks if a token is a value token
 // A value token is a token that is not a space, comment, or EOF
 func isValueToken(token *Token) bool {
-	return token.Type != EOF && token.Type != SPACE && token.Type != COMMENT && token.Type != MULTILINE_COMMENT
+	return token.Type != EOF && token.Type != SPACE && token.Type != COMMENT && token.Type != MULTILINE_COMMENT && token.Type != FEATURE_COMMENT && token.Type != CLIENT_DIRECTIVE
+}
+
+// isTableFunction checks if a token in a table position is a table function rather than a table,
+// e.g. UNNEST(...) or TABLE(sequence(...)) in Trino
+func isTableFunction(token *Token, dbms DBMSType) bool {
+	if dbms != DBMSTrino && dbms != DBMSDuckDB {
+		return false
+	}
+	// Trino and DuckDB do not allow scalar function calls in FROM, so any function there is a table function
+	return token.Type == FUNCTION || strings.EqualFold(token.Value, "UNNEST")
+}
+
+// isDuckDBFileReader checks if a function reads the file passed as its first argument
+func isDuckDBFileReader(function string) bool {
+	_, ok := duckDBFileReaders[strings.ToUpper(function)]
+	return ok
+}
+
+// isStarModifier checks if a word modifies a preceding wildcard, e.g. * EXCLUDE (col) in DuckDB
+func isStarModifier(word string) bool {
+	return strings.EqualFold(word, "EXCLUDE") || strings.EqualFold(word, "REPLACE") || strings.EqualFold(word, "RENAME")
+}
+
+// trimFilePath returns the path referenced by a file path token,
+// e.g. /mnt/path for delta.`/mnt/path` or data/*.parquet for 'data/*.parquet'
+func trimFilePath(token *Token) string {
+	if len(token.quotes) > 0 {
+		return token.Value[token.quotes[0]+1 : len(token.Value)-1]
+	}
+	if !isSingleQuote(rune(token.Value[0])) {
+		// unquoted local file path, e.g. file:///tmp/data.csv in Snowflake
+		return token.Value
+	}
+	return token.Value[1 : len(token.Value)-1]
+}
+
+// isStageStart checks if the rune following @ starts a Snowflake stage, e.g. @my_stage, @~ or @%orders
+func isStageStart(ch rune) bool {
+	return isAlphaNumeric(ch) || ch == '~' || ch == '%' || ch == '"'
+}
+
+// trimStagePath returns the stage of a stage reference without the path, e.g. @my_stage for @my_stage/path/
+func trimStagePath(stage string) string {
+	inQuotes := false
+	for i, ch := range stage {
+		if ch == '"' {
+			inQuotes = !inQuotes
+		} else if ch == '/' && !inQuotes {
+			return stage[:i]
+		}
+	}
+	return stage
 }